@@ -0,0 +1,126 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/hacel/jfsh/internal/jellyfin"
+)
+
+const commandsUsage = `Commands:
+  list resume|nextup|recent  list items of a tab
+  search <query>             search for movies and series
+  play <item-id|query>       play an item, or the first search result
+  mark watched|unwatched <item-id>
+                             mark an item as watched or unwatched`
+
+var errUsage = errors.New("invalid usage, see jfsh --help")
+
+// runCommand runs a non-interactive command for use in scripts and hotkeys. Item lists are printed in the output format
+// and items are played with the quality profile called quality.
+func runCommand(client *jellyfin.Client, args []string, output, quality string) error {
+	switch args[0] {
+	case "list":
+		if len(args) != 2 {
+			return errUsage
+		}
+		var items []jellyfin.Item
+		var err error
+		switch args[1] {
+		case "resume":
+			items, err = client.GetResume()
+		case "nextup":
+			items, err = client.GetNextUp()
+		case "recent":
+			items, _, err = client.GetRecentlyAdded(jellyfin.Query{Limit: pageSize})
+		default:
+			return fmt.Errorf("unknown list %q", args[1])
+		}
+		if err != nil {
+			return err
+		}
+		return printItems(os.Stdout, items, output)
+
+	case "search":
+		if len(args) < 2 {
+			return errUsage
+		}
+		items, _, err := client.Search(strings.Join(args[1:], " "), jellyfin.Query{Limit: pageSize})
+		if err != nil {
+			return err
+		}
+		return printItems(os.Stdout, items, output)
+
+	case "play":
+		if len(args) < 2 {
+			return errUsage
+		}
+		item, err := resolveItem(client, strings.Join(args[1:], " "))
+		if err != nil {
+			return err
+		}
+		if jellyfin.IsSeries(item) || jellyfin.IsSeason(item) {
+			if item, err = nextEpisode(client, item); err != nil {
+				return err
+			}
+		}
+		if jellyfin.IsLibrary(item) {
+			return fmt.Errorf("%q is not playable", item.GetName())
+		}
+		return play(client, item, false, "", playbackOptions(quality))
+
+	case "mark":
+		if len(args) != 3 {
+			return errUsage
+		}
+		item, err := client.GetItemByID(args[2])
+		if err != nil {
+			return err
+		}
+		switch args[1] {
+		case "watched":
+			return client.MarkAsWatched(item)
+		case "unwatched":
+			return client.MarkAsUnwatched(item)
+		default:
+			return fmt.Errorf("unknown status %q", args[1])
+		}
+
+	default:
+		return fmt.Errorf("unknown command %q", args[0])
+	}
+}
+
+// resolveItem returns the item with id arg if arg looks like an id, or the first search result for arg otherwise
+func resolveItem(client *jellyfin.Client, arg string) (jellyfin.Item, error) {
+	if _, err := uuid.Parse(arg); err == nil {
+		return client.GetItemByID(arg)
+	}
+	items, _, err := client.Search(arg, jellyfin.Query{Limit: 1})
+	if err != nil {
+		return jellyfin.Item{}, err
+	}
+	if len(items) == 0 {
+		return jellyfin.Item{}, fmt.Errorf("nothing found for %q", arg)
+	}
+	return items[0], nil
+}
+
+// nextEpisode returns the first episode of a series or season that hasn't been watched, or the first episode if all of them have
+func nextEpisode(client *jellyfin.Client, item jellyfin.Item) (jellyfin.Item, error) {
+	episodes, err := client.GetEpisodes(item)
+	if err != nil {
+		return jellyfin.Item{}, err
+	}
+	if len(episodes) == 0 {
+		return jellyfin.Item{}, fmt.Errorf("%q has no episodes", item.GetName())
+	}
+	idx := slices.IndexFunc(episodes, func(i jellyfin.Item) bool {
+		return !jellyfin.Watched(i)
+	})
+	return episodes[max(0, idx)], nil
+}
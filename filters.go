@@ -0,0 +1,211 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	"github.com/hacel/jfsh/internal/jellyfin"
+	"github.com/sj14/jellyfin-go/api"
+)
+
+// filter panel fields
+const (
+	filterTypeInput = iota
+	filterGenresInput
+	filterYearsInput
+	filterOfficialRatingsInput
+	filterMinRatingInput
+	filterPlayedInput
+)
+
+var filterInputLabels = []string{"Type", "Genres", "Years", "Rating", "Min score", "Status"}
+
+var filterTypes = map[string]api.BaseItemKind{
+	"movie":   api.BASEITEMKIND_MOVIE,
+	"series":  api.BASEITEMKIND_SERIES,
+	"episode": api.BASEITEMKIND_EPISODE,
+	"video":   api.BASEITEMKIND_VIDEO,
+}
+
+// splitList splits a comma separated list, dropping empty values
+func splitList(s string) []string {
+	var values []string
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
+}
+
+// parseYears parses "2019", "1990-1999", "1990-" and "-1999"
+func parseYears(s string) (minYear, maxYear int, err error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, 0, nil
+	}
+	from, to, isRange := strings.Cut(s, "-")
+	if from = strings.TrimSpace(from); from != "" {
+		if minYear, err = strconv.Atoi(from); err != nil {
+			return 0, 0, errors.New("years must look like 2019 or 1990-1999")
+		}
+	}
+	if !isRange {
+		return minYear, minYear, nil
+	}
+	if to = strings.TrimSpace(to); to != "" {
+		if maxYear, err = strconv.Atoi(to); err != nil {
+			return 0, 0, errors.New("years must look like 2019 or 1990-1999")
+		}
+	}
+	if minYear > 0 && maxYear > 0 && minYear > maxYear {
+		return 0, 0, errors.New("first year must not be after the last year")
+	}
+	return minYear, maxYear, nil
+}
+
+func newFilterInputs() []textinput.Model {
+	inputs := make([]textinput.Model, len(filterInputLabels))
+	for i := range inputs {
+		inputs[i] = textinput.New()
+		inputs[i].Prompt = ""
+		inputs[i].Width = 40
+	}
+
+	inputs[filterTypeInput].Placeholder = "movie, series, episode, video"
+	inputs[filterTypeInput].Validate = func(s string) error {
+		for _, t := range splitList(s) {
+			if _, ok := filterTypes[strings.ToLower(t)]; !ok {
+				return fmt.Errorf("unknown type %q", t)
+			}
+		}
+		return nil
+	}
+
+	inputs[filterGenresInput].Placeholder = "Comedy, Drama"
+
+	inputs[filterYearsInput].Placeholder = "1990-1999"
+	inputs[filterYearsInput].Validate = func(s string) error {
+		_, _, err := parseYears(s)
+		return err
+	}
+
+	inputs[filterOfficialRatingsInput].Placeholder = "PG-13, R"
+
+	inputs[filterMinRatingInput].Placeholder = "7.5"
+	inputs[filterMinRatingInput].Validate = func(s string) error {
+		if s = strings.TrimSpace(s); s == "" {
+			return nil
+		}
+		rating, err := strconv.ParseFloat(s, 64)
+		if err != nil || rating < 0 || rating > 10 {
+			return errors.New("must be a number between 0 and 10")
+		}
+		return nil
+	}
+
+	inputs[filterPlayedInput].Placeholder = "played, unplayed"
+	inputs[filterPlayedInput].Validate = func(s string) error {
+		switch strings.ToLower(strings.TrimSpace(s)) {
+		case "", "played", "unplayed":
+			return nil
+		}
+		return errors.New("must be played or unplayed")
+	}
+	return inputs
+}
+
+// setFilterInputs fills the filter panel with the values of f
+func setFilterInputs(inputs []textinput.Model, f jellyfin.Filter) {
+	var types []string
+	for _, t := range f.Types {
+		types = append(types, strings.ToLower(string(t)))
+	}
+	inputs[filterTypeInput].SetValue(strings.Join(types, ", "))
+	inputs[filterGenresInput].SetValue(strings.Join(f.Genres, ", "))
+	inputs[filterYearsInput].SetValue(formatYears(f.MinYear, f.MaxYear))
+	inputs[filterOfficialRatingsInput].SetValue(strings.Join(f.OfficialRatings, ", "))
+	minRating := ""
+	if f.MinCommunityRating > 0 {
+		minRating = strconv.FormatFloat(f.MinCommunityRating, 'f', -1, 64)
+	}
+	inputs[filterMinRatingInput].SetValue(minRating)
+	played := ""
+	if f.Played != nil {
+		played = "unplayed"
+		if *f.Played {
+			played = "played"
+		}
+	}
+	inputs[filterPlayedInput].SetValue(played)
+}
+
+// parseFilterInputs builds a filter out of the values in the filter panel
+func parseFilterInputs(inputs []textinput.Model) (jellyfin.Filter, error) {
+	for i, input := range inputs {
+		if input.Err != nil {
+			return jellyfin.Filter{}, fmt.Errorf("%s: %w", filterInputLabels[i], input.Err)
+		}
+	}
+
+	var f jellyfin.Filter
+	for _, t := range splitList(inputs[filterTypeInput].Value()) {
+		f.Types = append(f.Types, filterTypes[strings.ToLower(t)])
+	}
+	f.Genres = splitList(inputs[filterGenresInput].Value())
+	f.MinYear, f.MaxYear, _ = parseYears(inputs[filterYearsInput].Value())
+	f.OfficialRatings = splitList(inputs[filterOfficialRatingsInput].Value())
+	if s := strings.TrimSpace(inputs[filterMinRatingInput].Value()); s != "" {
+		f.MinCommunityRating, _ = strconv.ParseFloat(s, 64)
+	}
+	switch strings.ToLower(strings.TrimSpace(inputs[filterPlayedInput].Value())) {
+	case "played":
+		played := true
+		f.Played = &played
+	case "unplayed":
+		played := false
+		f.Played = &played
+	}
+	return f, nil
+}
+
+func formatYears(minYear, maxYear int) string {
+	switch {
+	case minYear == 0 && maxYear == 0:
+		return ""
+	case minYear == maxYear:
+		return strconv.Itoa(minYear)
+	case maxYear == 0:
+		return fmt.Sprintf("%d-", minYear)
+	case minYear == 0:
+		return fmt.Sprintf("-%d", maxYear)
+	}
+	return fmt.Sprintf("%d-%d", minYear, maxYear)
+}
+
+// filterSummary returns a short description of f to show next to the tabs
+func filterSummary(f jellyfin.Filter) string {
+	var parts []string
+	for _, t := range f.Types {
+		parts = append(parts, string(t))
+	}
+	parts = append(parts, f.Genres...)
+	if years := formatYears(f.MinYear, f.MaxYear); years != "" {
+		parts = append(parts, years)
+	}
+	parts = append(parts, f.OfficialRatings...)
+	if f.MinCommunityRating > 0 {
+		parts = append(parts, fmt.Sprintf("≥%.1f", f.MinCommunityRating))
+	}
+	if f.Played != nil {
+		if *f.Played {
+			parts = append(parts, "played")
+		} else {
+			parts = append(parts, "unplayed")
+		}
+	}
+	return strings.Join(parts, ", ")
+}
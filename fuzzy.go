@@ -0,0 +1,186 @@
+package main
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/hacel/jfsh/internal/jellyfin"
+)
+
+// scoring of fuzzy matches
+const (
+	scoreMatch       = 1
+	scoreConsecutive = 4
+	scoreWordStart   = 8
+	scoreFirstChar   = 4
+	penaltyGap       = 1
+	penaltyTypo      = 6
+	maxGapPenalty    = 8
+)
+
+func isWordStart(s []rune, i int) bool {
+	if i == 0 {
+		return true
+	}
+	prev, cur := s[i-1], s[i]
+	switch {
+	case !unicode.IsLetter(prev) && !unicode.IsDigit(prev):
+		return true
+	case unicode.IsLower(prev) && unicode.IsUpper(cur):
+		return true
+	case unicode.IsLetter(prev) != unicode.IsLetter(cur):
+		// e.g. the 3 in S03
+		return true
+	}
+	return false
+}
+
+// matchFrom greedily matches pattern against s starting at start, returning the score and the matched rune indices of s
+func matchFrom(pattern, s, lower []rune, start int) (int, []int, bool) {
+	positions := make([]int, 0, len(pattern))
+	score := 0
+	j := start
+	for _, p := range pattern {
+		gap := 0
+		for j < len(lower) && lower[j] != p {
+			j++
+			gap++
+		}
+		if j == len(lower) {
+			return 0, nil, false
+		}
+		score += scoreMatch
+		if len(positions) > 0 {
+			if gap == 0 {
+				score += scoreConsecutive
+			} else {
+				score -= min(gap*penaltyGap, maxGapPenalty)
+			}
+		}
+		if isWordStart(s, j) {
+			score += scoreWordStart
+		}
+		if j == 0 {
+			score += scoreFirstChar
+		}
+		positions = append(positions, j)
+		j++
+	}
+	return score, positions, true
+}
+
+// lowerRunes lowercases s rune by rune. Unlike strings.ToLower it never changes the number of runes, so indices into it
+// are indices into s.
+func lowerRunes(s string) []rune {
+	runes := []rune(s)
+	for i, r := range runes {
+		runes[i] = unicode.ToLower(r)
+	}
+	return runes
+}
+
+// fuzzyMatch matches the characters of pattern in order against s, ignoring case. Matches at the start of words and runs of
+// consecutive characters score higher. It returns the best score and the rune indices of s that matched.
+func fuzzyMatch(pattern, s string) (int, []int, bool) {
+	p := lowerRunes(pattern)
+	if len(p) == 0 {
+		return 0, nil, true
+	}
+	runes := []rune(s)
+	lower := lowerRunes(s)
+	bestScore, bestPositions, found := 0, []int(nil), false
+	for start, r := range lower {
+		if r != p[0] {
+			continue
+		}
+		score, positions, ok := matchFrom(p, runes, lower, start)
+		if ok && (!found || score > bestScore) {
+			bestScore, bestPositions, found = score, positions, true
+		}
+	}
+	return bestScore, bestPositions, found
+}
+
+// fuzzyMatchWithTypo is like fuzzyMatch, but tolerates one extra or swapped character in patterns of 4 or more characters
+func fuzzyMatchWithTypo(pattern, s string) (int, []int, bool) {
+	if score, positions, ok := fuzzyMatch(pattern, s); ok {
+		return score, positions, true
+	}
+	p := []rune(pattern)
+	if len(p) < 4 {
+		return 0, nil, false
+	}
+	bestScore, bestPositions, found := 0, []int(nil), false
+	for i := range p {
+		shorter := string(p[:i]) + string(p[i+1:])
+		score, positions, ok := fuzzyMatch(shorter, s)
+		if ok && (!found || score > bestScore) {
+			bestScore, bestPositions, found = score, positions, true
+		}
+	}
+	return bestScore - penaltyTypo, bestPositions, found
+}
+
+// filterQuery is a parsed local filter, e.g. "got s3 year:2011 type:episode"
+type filterQuery struct {
+	terms  []string
+	fields []func(jellyfin.Item) bool
+}
+
+func parseFilterQuery(s string) filterQuery {
+	var q filterQuery
+	for _, word := range strings.Fields(s) {
+		name, value, ok := strings.Cut(word, ":")
+		if !ok || value == "" {
+			q.terms = append(q.terms, word)
+			continue
+		}
+		switch strings.ToLower(name) {
+		case "year":
+			minYear, maxYear, err := parseYears(value)
+			if err != nil {
+				q.terms = append(q.terms, word)
+				continue
+			}
+			q.fields = append(q.fields, func(item jellyfin.Item) bool {
+				year := int(item.GetProductionYear())
+				return (minYear == 0 || year >= minYear) && (maxYear == 0 || year <= maxYear)
+			})
+		case "type":
+			value = strings.ToLower(value)
+			q.fields = append(q.fields, func(item jellyfin.Item) bool {
+				return strings.HasPrefix(strings.ToLower(string(item.GetType())), value)
+			})
+		default:
+			q.terms = append(q.terms, word)
+		}
+	}
+	return q
+}
+
+// match returns the score of item and the rune indices of its title that matched, if it matches every field and term of q.
+// Terms are matched against the title first and against the description at half the score otherwise.
+func (q filterQuery) match(item jellyfin.Item) (int, []int, bool) {
+	for _, field := range q.fields {
+		if !field(item) {
+			return 0, nil, false
+		}
+	}
+	title := jellyfin.GetItemTitle(item)
+	desc := jellyfin.GetItemDescription(item)
+	total := 0
+	var highlights []int
+	for _, term := range q.terms {
+		if score, positions, ok := fuzzyMatchWithTypo(term, title); ok {
+			total += score
+			highlights = append(highlights, positions...)
+			continue
+		}
+		if score, _, ok := fuzzyMatchWithTypo(term, desc); ok {
+			total += score / 2
+			continue
+		}
+		return 0, nil, false
+	}
+	return total, highlights, true
+}
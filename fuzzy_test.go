@@ -0,0 +1,123 @@
+package main
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/hacel/jfsh/internal/jellyfin"
+	"github.com/sj14/jellyfin-go/api"
+)
+
+func TestFuzzyMatch(t *testing.T) {
+	tests := []struct {
+		name       string
+		pattern    string
+		s          string
+		ok         bool
+		highlights []int
+	}{
+		{"empty pattern", "", "Dune", true, nil},
+		{"ignores case", "DUNE", "dune", true, []int{0, 1, 2, 3}},
+		{"word starts", "got", "Game of Thrones", true, []int{0, 5, 8}},
+		{"consecutive", "got", "Gotham", true, []int{0, 1, 2}},
+		{"in order only", "tog", "Gotham", false, nil},
+		{"season", "s3", "Show S03E01", true, []int{5, 7}},
+		// lowercasing İ takes two runes, the highlights must still be indices of the title
+		{"lowercase changes length", "dr", "İstanbul Dreams", true, []int{9, 10}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, highlights, ok := fuzzyMatch(tt.pattern, tt.s)
+			if ok != tt.ok {
+				t.Fatalf("fuzzyMatch(%q, %q) ok = %v, want %v", tt.pattern, tt.s, ok, tt.ok)
+			}
+			if !slices.Equal(highlights, tt.highlights) {
+				t.Errorf("fuzzyMatch(%q, %q) highlights = %v, want %v", tt.pattern, tt.s, highlights, tt.highlights)
+			}
+		})
+	}
+}
+
+func TestFuzzyMatchRanking(t *testing.T) {
+	tests := []struct {
+		pattern       string
+		better, worse string
+	}{
+		{"got", "Game of Thrones", "Gotham"},
+		{"dune", "Dune", "Dungeons and Dragons: Honor Among Thieves"},
+		{"sw", "Star Wars", "Showtime"},
+	}
+	for _, tt := range tests {
+		better, _, ok := fuzzyMatch(tt.pattern, tt.better)
+		if !ok {
+			t.Fatalf("fuzzyMatch(%q, %q) didn't match", tt.pattern, tt.better)
+		}
+		worse, _, ok := fuzzyMatch(tt.pattern, tt.worse)
+		if !ok {
+			t.Fatalf("fuzzyMatch(%q, %q) didn't match", tt.pattern, tt.worse)
+		}
+		if better <= worse {
+			t.Errorf("%q scores %d in %q, want more than %d in %q", tt.pattern, better, tt.better, worse, tt.worse)
+		}
+	}
+}
+
+func TestFuzzyMatchWithTypo(t *testing.T) {
+	if _, _, ok := fuzzyMatchWithTypo("duune", "Dune"); !ok {
+		t.Error("an extra character should be tolerated")
+	}
+	if _, _, ok := fuzzyMatchWithTypo("dnn", "Dune"); ok {
+		t.Error("typos should not be tolerated in short patterns")
+	}
+}
+
+func testFilterItem(name string, kind api.BaseItemKind, year int32) jellyfin.Item {
+	item := jellyfin.Item{}
+	item.SetId(name)
+	item.SetName(name)
+	item.SetType(kind)
+	item.SetProductionYear(year)
+	return item
+}
+
+func TestParseFilterQuery(t *testing.T) {
+	movie2019 := testFilterItem("Parasite", api.BASEITEMKIND_MOVIE, 2019)
+	movie2011 := testFilterItem("Drive", api.BASEITEMKIND_MOVIE, 2011)
+	series2011 := testFilterItem("Game of Thrones", api.BASEITEMKIND_SERIES, 2011)
+
+	tests := []struct {
+		query   string
+		terms   []string
+		matches []jellyfin.Item
+	}{
+		{"year:2019", nil, []jellyfin.Item{movie2019}},
+		{"year:2010-2012", nil, []jellyfin.Item{movie2011, series2011}},
+		{"type:movie", nil, []jellyfin.Item{movie2019, movie2011}},
+		{"TYPE:ser", nil, []jellyfin.Item{series2011}},
+		{"got s3", []string{"got", "s3"}, nil},
+		{"got year:2011", []string{"got"}, []jellyfin.Item{series2011}},
+		// not fields, so matched as text
+		{"year:soon type:", []string{"year:soon", "type:"}, nil},
+		{"imdb:tt123", []string{"imdb:tt123"}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			q := parseFilterQuery(tt.query)
+			if !slices.Equal(q.terms, tt.terms) {
+				t.Errorf("terms = %q, want %q", q.terms, tt.terms)
+			}
+			if tt.matches == nil {
+				return
+			}
+			var matches []jellyfin.Item
+			for _, item := range []jellyfin.Item{movie2019, movie2011, series2011} {
+				if _, _, ok := q.match(item); ok {
+					matches = append(matches, item)
+				}
+			}
+			if !slices.EqualFunc(matches, tt.matches, func(a, b jellyfin.Item) bool { return a.GetId() == b.GetId() }) {
+				t.Errorf("matched %d items, want %d", len(matches), len(tt.matches))
+			}
+		})
+	}
+}
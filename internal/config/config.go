@@ -2,9 +2,11 @@
 package config
 
 import (
+	"errors"
 	"log/slog"
 	"os"
 	"path/filepath"
+	"strings"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/google/uuid"
@@ -12,13 +14,14 @@ import (
 	"github.com/spf13/viper"
 )
 
-func Run(clientVersion, path string) *jellyfin.Client {
+// read reads the configuration file at path, creating it and filling in the settings shared by all profiles if needed
+func read(clientVersion, path string) error {
 	viper.SetConfigFile(path)
 	viper.SetConfigType("yaml")
 
 	// auto-create config dir
 	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
-		panic(err)
+		return err
 	}
 
 	configModified := false
@@ -28,71 +31,203 @@ func Run(clientVersion, path string) *jellyfin.Client {
 		configModified = true
 	}
 
+	if err := migrateProfiles(); err != nil {
+		return err
+	}
+
 	// get/set client variables
-	host := viper.GetString("host")
-	username := viper.GetString("username")
-	password := viper.GetString("password")
-	device := viper.GetString("device")
-	if device == "" {
-		device, _ = os.Hostname()
+	if viper.GetString("device") == "" {
+		device, _ := os.Hostname()
 		viper.Set("device", device)
 		configModified = true
 	}
-	deviceID := viper.GetString("device_id")
-	if deviceID == "" {
-		deviceID = uuid.NewString()
-		viper.Set("device_id", deviceID)
+	if viper.GetString("device_id") == "" {
+		viper.Set("device_id", uuid.NewString())
 		configModified = true
 	}
 	if viper.GetString("client_version") != clientVersion {
 		viper.Set("client_version", clientVersion)
 		configModified = true
 	}
-	token := viper.GetString("token")
-	userID := viper.GetString("user_id")
 
 	if configModified {
-		if err := viper.WriteConfig(); err != nil {
-			if err := viper.SafeWriteConfig(); err != nil {
-				panic(err)
+		return writeConfig()
+	}
+	return nil
+}
+
+// connection holds the settings of a profile needed to initialize its api client. They are read beforehand since
+// viper isn't safe for concurrent use and logging in, which can take a while, may happen in the background.
+type connection struct {
+	host, username, password, passwordCommand string
+	device, deviceID, clientVersion           string
+	token, userID                             string
+	opts                                      jellyfin.HTTPOptions
+}
+
+// readConnection reads the settings of the profile called name
+func readConnection(name string) (connection, error) {
+	c := connection{
+		host:            viper.GetString(profileKey(name, "host")),
+		username:        viper.GetString(profileKey(name, "username")),
+		password:        viper.GetString(profileKey(name, "password")),
+		passwordCommand: viper.GetString(profileKey(name, "password_command")),
+		device:          viper.GetString("device"),
+		deviceID:        viper.GetString("device_id"),
+		clientVersion:   viper.GetString("client_version"),
+		token:           viper.GetString(profileKey(name, "token")),
+		userID:          viper.GetString(profileKey(name, "user_id")),
+		opts:            httpOptions(name),
+	}
+	if c.host == "" || c.username == "" {
+		return connection{}, errors.New("host and username are not configured")
+	}
+	return c, nil
+}
+
+// login initializes the api client, logging in with the password if there is no token. It doesn't touch the config.
+func (c connection) login() (*jellyfin.Client, error) {
+	var password string
+	if c.token == "" || c.userID == "" {
+		password = c.password
+		if password == "" {
+			var err error
+			if password, err = runPasswordCommand(c.passwordCommand); err != nil {
+				return nil, err
 			}
 		}
 	}
+	return jellyfin.NewClient(
+		c.host,
+		c.username,
+		password,
+		c.device,
+		c.deviceID,
+		c.clientVersion,
+		c.token,
+		c.userID,
+		c.opts,
+	)
+}
 
-	// short circuit if we can already make a client
-	if host != "" && username != "" {
-		client, err := jellyfin.NewClient(
-			host,
-			username,
-			password,
-			device,
-			deviceID,
-			clientVersion,
-			token,
-			userID,
-		)
-		if err == nil {
-			if client.Token != token || client.UserID != userID {
-				viper.Set("token", client.Token)
-				viper.Set("user_id", client.UserID)
-				slog.Info("updating token and user id", "token", client.Token, "user_id", client.UserID)
-				if err := viper.WriteConfig(); err != nil {
-					if err := viper.SafeWriteConfig(); err != nil {
-						panic(err)
-					}
-				}
-			}
-			return client
+// saveLogin makes the profile called name the one in use and stores the token and user id client got
+func saveLogin(name string, client *jellyfin.Client) error {
+	profile = name
+	if client.Token != viper.GetString(key("token")) || client.UserID != viper.GetString(key("user_id")) {
+		viper.Set(key("token"), client.Token)
+		viper.Set(key("user_id"), client.UserID)
+		slog.Info("updating token and user id", "profile", profile, "token", client.Token, "user_id", client.UserID)
+		if err := writeConfig(); err != nil {
+			return err
 		}
-		slog.Error("failed to create client", "err", err)
 	}
+	// the token is all that's needed from now on
+	if err := forgetPassword(); err != nil {
+		return err
+	}
+	enableReauthentication(client)
+	return nil
+}
+
+// Load reads the configuration file at path, creating it if needed, and initializes the api client of the profile
+// called name, or of the last profile picked if name is empty, without any user interaction. It fails if the profile is
+// incomplete or the server rejects it.
+func Load(clientVersion, path, name string) (*jellyfin.Client, error) {
+	if err := read(clientVersion, path); err != nil {
+		return nil, err
+	}
+	if name == "" {
+		name = viper.GetString("profile")
+	}
+	if name == "" {
+		name = defaultProfile
+	}
+	if err := validateProfileName(name); err != nil {
+		return nil, errors.New("invalid profile name: " + err.Error())
+	}
+	profile = name
+	c, err := readConnection(name)
+	if err != nil {
+		return nil, err
+	}
+	client, err := c.login()
+	if err != nil {
+		return nil, err
+	}
+	// the profile used by default only changes when the user picks another one, not with --profile
+	if err := saveLogin(name, client); err != nil {
+		return nil, err
+	}
+	return client, nil
+}
+
+// writeConfig writes the config file, creating it if it doesn't exist
+func writeConfig() error {
+	if err := viper.WriteConfig(); err != nil {
+		return viper.SafeWriteConfig()
+	}
+	return nil
+}
+
+// Write writes settings changed outside of this package to the config file, creating it if it doesn't exist
+func Write() error {
+	return writeConfig()
+}
+
+// unset removes key from the config file. Viper can't delete keys, so the config is reloaded without it.
+func unset(key string) error {
+	settings := viper.AllSettings()
+	path := strings.Split(key, ".")
+	parent := settings
+	for _, k := range path[:len(path)-1] {
+		next, ok := parent[k].(map[string]any)
+		if !ok {
+			return nil
+		}
+		parent = next
+	}
+	delete(parent, path[len(path)-1])
+	return replaceSettings(settings)
+}
+
+// replaceSettings replaces everything in the config file with settings
+func replaceSettings(settings map[string]any) error {
+	path := viper.ConfigFileUsed()
+	viper.Reset()
+	viper.SetConfigFile(path)
+	viper.SetConfigType("yaml")
+	if err := viper.MergeConfigMap(settings); err != nil {
+		return err
+	}
+	return writeConfig()
+}
+
+// Run is like Load but falls back to a form asking for the server and credentials when the profile doesn't work
+func Run(clientVersion, path, name string) *jellyfin.Client {
+	// short circuit if we can already make a client
+	client, err := Load(clientVersion, path, name)
+	if err == nil {
+		return client
+	}
+	slog.Error("failed to create client", "profile", profile, "err", err)
 
 	// run the bubbletea form model otherwise
-	m, err := tea.NewProgram(initialModel(), tea.WithAltScreen()).Run()
+	return run(initialModel(profile))
+}
+
+// Pick shows the saved profiles to switch to, add or remove one. It returns nil if the user quit.
+func Pick(clientVersion, path string) *jellyfin.Client {
+	if err := read(clientVersion, path); err != nil {
+		panic(err)
+	}
+	return run(initialPickerModel())
+}
+
+func run(m model) *jellyfin.Client {
+	res, err := tea.NewProgram(m, tea.WithAltScreen()).Run()
 	if err != nil {
 		panic(err)
 	}
 	// the model should've created a valid client
-	client := m.(model).client
-	return client
+	return res.(model).client
 }
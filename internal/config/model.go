@@ -3,6 +3,7 @@ package config
 import (
 	"errors"
 	"net/url"
+	"slices"
 
 	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
@@ -12,7 +13,8 @@ import (
 
 // form fields
 const (
-	hostInput = iota
+	profileInput = iota
+	hostInput
 	usernameInput
 	passwordInput
 )
@@ -26,15 +28,65 @@ type model struct {
 
 	inputs       []textinput.Model
 	currentInput int
+
+	// pending quick connect login, the form is replaced by its code while it is set
+	quickConnect *jellyfin.QuickConnect
+
+	// the profile picker is shown instead of the form while picking is set
+	picking  bool
+	profiles []string
+	cursor   int
+
+	// servers found on the local network, listed instead of the rest of the form while pickingServer is set
+	discovering   bool
+	servers       []jellyfin.Server
+	pickingServer bool
+	serverCursor  int
+
+	// result of checking the server at probedHost, shown while the host input still holds it
+	probedHost string
+	server     jellyfin.ServerInfo
+	probeErr   error
+}
+
+// initialModel returns the form filled in with the settings of the profile called name
+func initialModel(name string) model {
+	m := model{inputs: newForm(name)}
+	m.currentInput = hostInput
+	if name == "" {
+		m.currentInput = profileInput
+	}
+	m.inputs[m.currentInput].Focus()
+	// offer the servers on the local network unless the host is already known
+	m.discovering = m.inputs[hostInput].Value() == ""
+	return m
+}
+
+func initialPickerModel() model {
+	m := model{picking: true, profiles: profiles()}
+	m.cursor = max(slices.Index(m.profiles, viper.GetString("profile")), 0)
+	return m
 }
 
-func initialModel() model {
-	form := make([]textinput.Model, 3)
+// newForm returns the inputs of the form filled in with the settings of the profile called name, empty if name is
+func newForm(name string) []textinput.Model {
+	get := func(setting string) string {
+		if name == "" {
+			return ""
+		}
+		return viper.GetString(profileKey(name, setting))
+	}
+
+	form := make([]textinput.Model, 4)
+
+	form[profileInput] = textinput.New()
+	form[profileInput].Prompt = ""
+	form[profileInput].SetValue(name)
+	form[profileInput].Validate = validateProfileName
 
 	form[hostInput] = textinput.New()
-	form[hostInput].Focus()
 	form[hostInput].Prompt = ""
-	form[hostInput].SetValue(viper.GetString("host"))
+	form[hostInput].SetValue(get("host"))
 	form[hostInput].Validate = func(s string) error {
 		u, err := url.Parse(s)
 		if err != nil {
@@ -51,16 +103,22 @@ func initialModel() model {
 
 	form[usernameInput] = textinput.New()
 	form[usernameInput].Prompt = ""
-	form[usernameInput].SetValue(viper.GetString("username"))
+	form[usernameInput].SetValue(get("username"))
 
 	form[passwordInput] = textinput.New()
 	form[passwordInput].Prompt = ""
 	form[passwordInput].EchoMode = textinput.EchoPassword
-	form[passwordInput].SetValue(viper.GetString("password"))
+	form[passwordInput].SetValue(get("password"))
 
-	return model{inputs: form}
+	return form
 }
 
 func (m model) Init() tea.Cmd {
-	return textinput.Blink
+	if m.picking {
+		return nil
+	}
+	if m.discovering {
+		return tea.Batch(textinput.Blink, discover(false))
+	}
+	return tea.Batch(textinput.Blink, probe(m.inputs[hostInput].Value(), httpOptions(m.inputs[profileInput].Value())))
 }
@@ -0,0 +1,62 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/hacel/jfsh/internal/jellyfin"
+	"github.com/spf13/viper"
+)
+
+// runPasswordCommand returns the first line printed by command, or nothing if command is empty
+func runPasswordCommand(command string) (string, error) {
+	if command == "" {
+		return "", nil
+	}
+	cmd := shellCommand(command)
+	stderr := &bytes.Buffer{}
+	cmd.Stderr = stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("password_command failed: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	// only the first line, like pass and most password managers print it
+	password, _, _ := strings.Cut(string(out), "\n")
+	return strings.TrimSuffix(password, "\r"), nil
+}
+
+// enableReauthentication lets client log in again with the password from password_command when its token is rejected,
+// and stores the new token in the profile in use. The client logs in again from the goroutines requests are sent on
+// while viper isn't safe for concurrent use, so the settings are read beforehand and programs that send requests from
+// several goroutines have to replace TokenChanged with one that calls SaveToken from a single goroutine.
+func enableReauthentication(client *jellyfin.Client) {
+	password, command := viper.GetString(key("password")), viper.GetString(key("password_command"))
+	client.Password = func() (string, error) {
+		if password != "" {
+			return password, nil
+		}
+		return runPasswordCommand(command)
+	}
+	client.TokenChanged = func(token string) {
+		if err := SaveToken(token); err != nil {
+			slog.Error("failed to write config", "err", err)
+		}
+	}
+}
+
+// SaveToken stores token in the profile in use
+func SaveToken(token string) error {
+	viper.Set(key("token"), token)
+	return writeConfig()
+}
+
+// forgetPassword removes the password of the profile in use from the config file once it has been exchanged for a token
+func forgetPassword() error {
+	if viper.GetString(key("password")) == "" {
+		return nil
+	}
+	slog.Info("removing password from config")
+	return unset(key("password"))
+}
@@ -0,0 +1,107 @@
+package config
+
+import (
+	"errors"
+	"log/slog"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/hacel/jfsh/internal/jellyfin"
+	"github.com/spf13/viper"
+)
+
+// settings stored separately for each profile, everything else in the config file is shared by all of them
+var profileSettings = []string{"host", "username", "user_id", "token", "password", "password_command", "tls", "headers"}
+
+// profile that settings written before profiles existed are moved to
+const defaultProfile = "default"
+
+// profile is the name of the profile in use
+var profile = defaultProfile
+
+// key returns the key of setting in the profile in use
+func key(setting string) string {
+	return profileKey(profile, setting)
+}
+
+func profileKey(name, setting string) string {
+	return "profiles." + name + "." + setting
+}
+
+// httpOptions returns the TLS settings and extra headers of the profile called name
+func httpOptions(name string) jellyfin.HTTPOptions {
+	return jellyfin.HTTPOptions{
+		CAFile:             viper.GetString(profileKey(name, "tls.ca_file")),
+		CertFile:           viper.GetString(profileKey(name, "tls.cert_file")),
+		KeyFile:            viper.GetString(profileKey(name, "tls.key_file")),
+		InsecureSkipVerify: viper.GetBool(profileKey(name, "tls.insecure_skip_verify")),
+		Headers:            viper.GetStringMapString(profileKey(name, "headers")),
+	}
+}
+
+// profiles returns the names of the saved profiles in alphabetical order
+func profiles() []string {
+	// GetStringMap doesn't merge profiles in the config file with ones that have just been set
+	all, _ := viper.AllSettings()["profiles"].(map[string]any)
+	names := slices.Collect(maps.Keys(all))
+	slices.Sort(names)
+	return names
+}
+
+// validateProfileName makes sure name can be used as a key, viper keys being case insensitive and split on dots
+func validateProfileName(name string) error {
+	switch {
+	case name == "":
+		return errors.New("required")
+	case strings.ContainsAny(name, ". \t"):
+		return errors.New("must not contain dots or spaces")
+	case name != strings.ToLower(name):
+		return errors.New("must be lowercase")
+	}
+	return nil
+}
+
+// saveProfile makes the profile in use the one used when jfsh is started without --profile, once the user picked it
+func saveProfile() error {
+	if viper.GetString("profile") == profile {
+		return nil
+	}
+	viper.Set("profile", profile)
+	return writeConfig()
+}
+
+// removeProfile deletes the profile called name from the config file
+func removeProfile(name string) error {
+	return unset("profiles." + name)
+}
+
+// migrateProfiles moves the settings of config files written before profiles existed into the default profile
+func migrateProfiles() error {
+	settings := viper.AllSettings()
+	moved := make(map[string]any)
+	for _, setting := range profileSettings {
+		if v, ok := settings[setting]; ok {
+			moved[setting] = v
+			delete(settings, setting)
+		}
+	}
+	if len(moved) == 0 {
+		return nil
+	}
+	all, _ := settings["profiles"].(map[string]any)
+	if all == nil {
+		all = make(map[string]any)
+	}
+	if existing, ok := all[defaultProfile].(map[string]any); ok {
+		// settings already in the profile are newer
+		maps.Copy(moved, existing)
+	}
+	all[defaultProfile] = moved
+	settings["profiles"] = all
+	if _, ok := settings["profile"]; !ok {
+		settings["profile"] = defaultProfile
+	}
+	slog.Info("moving settings into the default profile")
+	return replaceSettings(settings)
+}
@@ -1,15 +1,29 @@
 package config
 
 import (
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/hacel/jfsh/internal/jellyfin"
 	"github.com/spf13/viper"
 )
 
 func (m *model) initClient() tea.Cmd {
-	host, username, password := m.inputs[hostInput].Value(), m.inputs[usernameInput].Value(), m.inputs[passwordInput].Value()
-	device, deviceID, clientVersion, token, userID := viper.GetString("device"), viper.GetString("device_id"), viper.GetString("client_version"), viper.GetString("token"), viper.GetString("user_id")
+	name, host, username, password := m.inputs[profileInput].Value(), m.inputs[hostInput].Value(), m.inputs[usernameInput].Value(), m.inputs[passwordInput].Value()
+	device, deviceID, clientVersion := viper.GetString("device"), viper.GetString("device_id"), viper.GetString("client_version")
+	// the saved token is only valid for the server and user it was issued for
+	var token, userID string
+	if host == viper.GetString(profileKey(name, "host")) && username == viper.GetString(profileKey(name, "username")) {
+		token, userID = viper.GetString(profileKey(name, "token")), viper.GetString(profileKey(name, "user_id"))
+	}
+	opts := httpOptions(name)
 	return func() tea.Msg {
+		if _, err := jellyfin.Probe(host, device, deviceID, clientVersion, opts); err != nil {
+			return err
+		}
 		client, err := jellyfin.NewClient(
 			host,
 			username,
@@ -19,6 +33,7 @@ func (m *model) initClient() tea.Cmd {
 			clientVersion,
 			token,
 			userID,
+			opts,
 		)
 		if err != nil {
 			return err
@@ -27,7 +42,124 @@ func (m *model) initClient() tea.Cmd {
 	}
 }
 
+type connectResult struct {
+	name   string
+	client *jellyfin.Client
+	err    error
+}
+
+// connectProfile initializes the client of a saved profile. Only logging in happens in the background, the config is
+// read beforehand and saved once the result is back.
+func connectProfile(name string) tea.Cmd {
+	c, err := readConnection(name)
+	return func() tea.Msg {
+		if err != nil {
+			return connectResult{name, nil, err}
+		}
+		client, err := c.login()
+		return connectResult{name, client, err}
+	}
+}
+
+// openForm replaces the profile picker with the form for the profile called name, or a new profile if name is empty
+func (m *model) openForm(name string) {
+	form := initialModel(name)
+	m.picking = false
+	m.inputs = form.inputs
+	m.currentInput = form.currentInput
+	m.discovering = form.discovering
+	m.pickingServer = false
+}
+
+// openPicker replaces the form with the profile picker
+func (m *model) openPicker() {
+	picker := initialPickerModel()
+	m.picking = true
+	m.profiles = picker.profiles
+	m.cursor = picker.cursor
+	m.quickConnect = nil
+	m.err = nil
+}
+
+type probeResult struct {
+	host string
+	info jellyfin.ServerInfo
+	err  error
+}
+
+// probe checks the server at host so that problems show up before submitting the form
+func probe(host string, opts jellyfin.HTTPOptions) tea.Cmd {
+	device, deviceID, clientVersion := viper.GetString("device"), viper.GetString("device_id"), viper.GetString("client_version")
+	return func() tea.Msg {
+		info, err := jellyfin.Probe(host, device, deviceID, clientVersion, opts)
+		return probeResult{host, info, err}
+	}
+}
+
+// probeHost checks the server in the host input unless it's invalid or has already been checked
+func (m *model) probeHost() tea.Cmd {
+	host := m.inputs[hostInput].Value()
+	if host == "" || m.inputs[hostInput].Err != nil || host == m.probedHost {
+		return nil
+	}
+	m.probedHost = host
+	m.server = jellyfin.ServerInfo{}
+	m.probeErr = nil
+	return probe(host, httpOptions(m.inputs[profileInput].Value()))
+}
+
+// how long to wait for servers on the local network to answer
+const discoveryTimeout = 2 * time.Second
+
+type discoveryResult struct {
+	servers []jellyfin.Server
+	// whether the user asked for it, otherwise finding nothing isn't worth reporting
+	requested bool
+	err       error
+}
+
+// discover looks for servers on the local network
+func discover(requested bool) tea.Cmd {
+	return func() tea.Msg {
+		servers, err := jellyfin.Discover(discoveryTimeout)
+		return discoveryResult{servers, requested, err}
+	}
+}
+
+// how often the server is asked whether the quick connect code has been entered
+const quickConnectPollInterval = 2 * time.Second
+
+type quickConnectState struct {
+	quickConnect *jellyfin.QuickConnect
+	authorized   bool
+	err          error
+}
+
+func (m *model) initQuickConnect() tea.Cmd {
+	host, device, deviceID, clientVersion := m.inputs[hostInput].Value(), viper.GetString("device"), viper.GetString("device_id"), viper.GetString("client_version")
+	opts := httpOptions(m.inputs[profileInput].Value())
+	return func() tea.Msg {
+		if _, err := jellyfin.Probe(host, device, deviceID, clientVersion, opts); err != nil {
+			return err
+		}
+		quickConnect, err := jellyfin.InitiateQuickConnect(host, device, deviceID, clientVersion, opts)
+		if err != nil {
+			return err
+		}
+		return quickConnect
+	}
+}
+
+// pollQuickConnect waits a bit and checks whether the quick connect code has been entered
+func pollQuickConnect(quickConnect *jellyfin.QuickConnect) tea.Cmd {
+	return tea.Tick(quickConnectPollInterval, func(time.Time) tea.Msg {
+		authorized, err := quickConnect.Authorized()
+		return quickConnectState{quickConnect, authorized, err}
+	})
+}
+
 func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	var probeCmd tea.Cmd
 	switch msg := msg.(type) {
 	case error:
 		m.err = msg
@@ -38,27 +170,151 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.width = msg.Width
 		return m, nil
 
-	case *jellyfin.Client:
-		host, username, password := m.inputs[hostInput].Value(), m.inputs[usernameInput].Value(), m.inputs[passwordInput].Value()
-		viper.Set("host", host)
-		viper.Set("username", username)
-		viper.Set("password", password)
-		viper.Set("user_id", msg.UserID)
-		viper.Set("token", msg.Token)
-		if err := viper.WriteConfig(); err != nil {
-			if err := viper.SafeWriteConfig(); err != nil {
-				panic(err)
+	case discoveryResult:
+		m.discovering = false
+		if msg.err != nil {
+			if msg.requested {
+				m.err = msg.err
+			} else {
+				slog.Error("failed to look for servers", "err", msg.err)
+			}
+			return m, nil
+		}
+		if len(msg.servers) == 0 {
+			if msg.requested {
+				m.err = errors.New("no servers found on the local network")
+			}
+			return m, nil
+		}
+		if !msg.requested && m.inputs[hostInput].Value() != "" {
+			// the user didn't wait
+			return m, nil
+		}
+		m.servers = msg.servers
+		m.serverCursor = 0
+		m.pickingServer = true
+		return m, nil
+
+	case probeResult:
+		if msg.host != m.inputs[hostInput].Value() {
+			// outdated
+			return m, nil
+		}
+		m.probedHost = msg.host
+		m.server = msg.info
+		m.probeErr = msg.err
+		return m, nil
+
+	case *jellyfin.QuickConnect:
+		m.err = nil
+		m.quickConnect = msg
+		return m, pollQuickConnect(msg)
+
+	case quickConnectState:
+		if msg.quickConnect != m.quickConnect {
+			// cancelled
+			return m, nil
+		}
+		if msg.err != nil {
+			m.err = msg.err
+			m.quickConnect = nil
+			return m, nil
+		}
+		if !msg.authorized {
+			return m, pollQuickConnect(msg.quickConnect)
+		}
+		quickConnect := msg.quickConnect
+		return m, func() tea.Msg {
+			client, err := quickConnect.NewClient()
+			if err != nil {
+				return err
 			}
+			return client
+		}
+
+	case connectResult:
+		if msg.err != nil {
+			// let the user fix the profile
+			m.openForm(msg.name)
+			m.err = msg.err
+			return m, m.probeHost()
+		}
+		if err := saveLogin(msg.name, msg.client); err != nil {
+			m.err = err
+			return m, nil
+		}
+		if err := saveProfile(); err != nil {
+			m.err = err
+			return m, nil
+		}
+		m.client = msg.client
+		return m, tea.Quit
+
+	case *jellyfin.Client:
+		profile = m.inputs[profileInput].Value()
+		viper.Set(key("host"), m.inputs[hostInput].Value())
+		viper.Set(key("username"), msg.Username)
+		viper.Set(key("user_id"), msg.UserID)
+		viper.Set(key("token"), msg.Token)
+		viper.Set("profile", profile)
+		// only the token is stored, the password is read from password_command when a new one is needed
+		if err := forgetPassword(); err != nil {
+			m.err = err
+			return m, nil
+		}
+		if err := writeConfig(); err != nil {
+			m.err = err
+			return m, nil
 		}
+		enableReauthentication(msg)
 		m.client = msg
 		return m, tea.Quit
 
 	case tea.KeyMsg:
+		if m.picking {
+			return m.updatePicker(msg)
+		}
+
+		if m.quickConnect != nil {
+			switch msg.Type {
+			case tea.KeyCtrlC:
+				return m, tea.Quit
+			case tea.KeyEsc:
+				m.quickConnect = nil
+			}
+			return m, nil
+		}
+
+		if m.pickingServer {
+			return m.updateServerPicker(msg)
+		}
+
 		switch msg.Type {
 
+		case tea.KeyCtrlF:
+			if !m.discovering {
+				m.err = nil
+				m.discovering = true
+				return m, discover(true)
+			}
+
+		case tea.KeyCtrlQ:
+			// the profile is saved under its name once Quick Connect is authorized
+			if err := validateProfileName(m.inputs[profileInput].Value()); err != nil {
+				m.inputs[profileInput].Err = err
+				m.currentInput = profileInput
+				break
+			}
+			if m.inputs[hostInput].Err == nil && m.inputs[hostInput].Value() != "" {
+				return m, m.initQuickConnect()
+			}
+
 		case tea.KeyEnter:
 			if m.currentInput == len(m.inputs)-1 {
 				valid := true
+				if m.inputs[profileInput].Err != nil || m.inputs[profileInput].Value() == "" {
+					valid = false
+				}
 				if m.inputs[hostInput].Err != nil || m.inputs[hostInput].Value() == "" {
 					valid = false
 				}
@@ -74,9 +330,16 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 			m.currentInput = (m.currentInput + 1) % len(m.inputs)
 
-		case tea.KeyCtrlC, tea.KeyEsc:
+		case tea.KeyCtrlC:
 			return m, tea.Quit
 
+		case tea.KeyEsc:
+			if len(profiles()) == 0 {
+				return m, tea.Quit
+			}
+			m.openPicker()
+			return m, nil
+
 		case tea.KeyShiftTab, tea.KeyCtrlP, tea.KeyUp:
 			m.currentInput--
 			if m.currentInput < 0 {
@@ -91,11 +354,92 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.inputs[i].Blur()
 		}
 		m.inputs[m.currentInput].Focus()
+		if m.currentInput != hostInput {
+			probeCmd = m.probeHost()
+		}
 	}
 
-	cmds := make([]tea.Cmd, len(m.inputs))
+	cmds := make([]tea.Cmd, len(m.inputs), len(m.inputs)+1)
 	for i := range m.inputs {
 		m.inputs[i], cmds[i] = m.inputs[i].Update(msg)
 	}
+	cmds = append(cmds, probeCmd)
 	return m, tea.Batch(cmds...)
 }
+
+func (m model) updatePicker(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	m.err = nil
+	switch msg.String() {
+	case "ctrl+c", "q":
+		return m, tea.Quit
+
+	case "esc":
+		// back to the profile that was in use
+		return m, connectProfile(viper.GetString("profile"))
+
+	case "up", "k", "shift+tab", "ctrl+p":
+		m.cursor = max(m.cursor-1, 0)
+
+	case "down", "j", "tab", "ctrl+n":
+		m.cursor = min(m.cursor+1, max(len(m.profiles)-1, 0))
+
+	case "enter", " ":
+		if m.cursor < len(m.profiles) {
+			return m, connectProfile(m.profiles[m.cursor])
+		}
+
+	case "a":
+		m.openForm("")
+		return m, tea.Batch(textinput.Blink, discover(false))
+
+	case "e":
+		if m.cursor < len(m.profiles) {
+			m.openForm(m.profiles[m.cursor])
+			return m, tea.Batch(textinput.Blink, m.probeHost())
+		}
+
+	case "d":
+		if m.cursor >= len(m.profiles) {
+			break
+		}
+		name := m.profiles[m.cursor]
+		if name == viper.GetString("profile") {
+			m.err = errors.New("can't remove the profile in use, switch to another one first")
+			break
+		}
+		if err := removeProfile(name); err != nil {
+			m.err = err
+			break
+		}
+		m.profiles = profiles()
+		m.cursor = min(m.cursor, max(len(m.profiles)-1, 0))
+	}
+	return m, nil
+}
+
+// updateServerPicker handles keys while the servers found on the local network are listed
+func (m model) updateServerPicker(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "ctrl+c":
+		return m, tea.Quit
+
+	case "esc":
+		// type the host instead
+		m.pickingServer = false
+
+	case "up", "k", "shift+tab", "ctrl+p":
+		m.serverCursor = max(m.serverCursor-1, 0)
+
+	case "down", "j", "tab", "ctrl+n":
+		m.serverCursor = min(m.serverCursor+1, len(m.servers)-1)
+
+	case "enter", " ":
+		m.pickingServer = false
+		m.inputs[hostInput].SetValue(m.servers[m.serverCursor].Address)
+		m.inputs[m.currentInput].Blur()
+		m.currentInput = usernameInput
+		m.inputs[m.currentInput].Focus()
+		return m, tea.Batch(textinput.Blink, m.probeHost())
+	}
+	return m, nil
+}
@@ -1,6 +1,9 @@
 package config
 
-import "github.com/charmbracelet/lipgloss"
+import (
+	"github.com/charmbracelet/lipgloss"
+	"github.com/spf13/viper"
+)
 
 var (
 	pinkColor       = lipgloss.Color("#923FAD")
@@ -12,9 +15,24 @@ var (
 	labelStyle = lipgloss.NewStyle().Margin(0, 1, 0, 1).Foreground(brightPinkColor)
 	inputStyle = lipgloss.NewStyle().Foreground(textColor)
 	errStyle   = lipgloss.NewStyle().Margin(0, 0, 0, 1).Foreground(dimTextColor)
+	hintStyle  = errStyle
+	codeStyle  = inputStyle.Bold(true)
+
+	profileStyle        = lipgloss.NewStyle().Margin(0, 0, 0, 1).Padding(0, 0, 0, 2).Foreground(textColor)
+	currentProfileStyle = lipgloss.NewStyle().
+				Margin(0, 0, 0, 1).
+				Padding(0, 0, 0, 1).
+				Foreground(brightPinkColor).
+				Border(lipgloss.NormalBorder(), false, false, false, true).
+				BorderForeground(brightPinkColor).
+				Bold(true)
 )
 
 func (m model) View() string {
+	if m.picking {
+		return m.pickerView()
+	}
+
 	sections := make([]string, 0, 2+len(m.inputs))
 
 	{
@@ -22,17 +40,67 @@ func (m model) View() string {
 		sections = append(sections, title)
 	}
 
+	{
+		label := labelStyle.Render("Profile")
+		input := inputStyle.Render(m.inputs[profileInput].View())
+		err := ""
+		if e := m.inputs[profileInput].Err; e != nil {
+			err = e.Error()
+		}
+		err = errStyle.Render(err)
+		sections = append(sections, lipgloss.JoinHorizontal(lipgloss.Top, label, input), err)
+	}
+
 	{
 		label := labelStyle.Render("Host")
 		input := inputStyle.Render(m.inputs[hostInput].View())
 		err := ""
 		if e := m.inputs[hostInput].Err; e != nil {
 			err = e.Error()
+		} else if m.probedHost != "" && m.probedHost == m.inputs[hostInput].Value() {
+			switch {
+			case m.probeErr != nil:
+				err = m.probeErr.Error()
+			case m.server.ID != "":
+				err = m.server.Name + " • Jellyfin " + m.server.Version
+			default:
+				err = "Checking the server..."
+			}
 		}
 		err = errStyle.Render(err)
 		sections = append(sections, lipgloss.JoinHorizontal(lipgloss.Top, label, input), err)
 	}
 
+	if m.discovering {
+		sections = append(sections, hintStyle.Render("Looking for servers on the local network..."))
+	}
+
+	if m.pickingServer {
+		sections = append(sections, labelStyle.Render("Servers on the local network"))
+		for i, server := range m.servers {
+			line := server.Name + " " + hintStyle.UnsetMargins().Render(server.Address)
+			if i == m.serverCursor {
+				sections = append(sections, currentProfileStyle.Render(line))
+			} else {
+				sections = append(sections, profileStyle.Render(line))
+			}
+		}
+		sections = append(sections, "", hintStyle.Render("enter select • esc type the host instead"))
+		content := lipgloss.JoinVertical(lipgloss.Left, sections...)
+		content = lipgloss.NewStyle().Width(m.width/2 + 10).Height(m.height / 2).Render(content)
+		return lipgloss.Place(m.width, m.height, lipgloss.Center, lipgloss.Center, content)
+	}
+
+	if m.quickConnect != nil {
+		label := labelStyle.Render("Quick Connect code")
+		code := codeStyle.Render(m.quickConnect.Code)
+		hint := hintStyle.Render("Enter the code in Quick Connect in the user settings of a signed in Jellyfin client.\nWaiting for authorization, press esc to cancel.")
+		sections = append(sections, "", lipgloss.JoinHorizontal(lipgloss.Top, label, code), "", hint)
+		content := lipgloss.JoinVertical(lipgloss.Left, sections...)
+		content = lipgloss.NewStyle().Width(m.width/2 + 10).Height(m.height / 2).Render(content)
+		return lipgloss.Place(m.width, m.height, lipgloss.Center, lipgloss.Center, content)
+	}
+
 	{
 		label := labelStyle.Render("Username")
 		input := inputStyle.Render(m.inputs[usernameInput].View())
@@ -55,6 +123,14 @@ func (m model) View() string {
 		sections = append(sections, lipgloss.JoinHorizontal(lipgloss.Top, label, input), err)
 	}
 
+	{
+		sections = append(sections, hintStyle.Render("Press ctrl+f to look for servers on the local network."))
+		sections = append(sections, hintStyle.Render("Press ctrl+q to log in with Quick Connect instead."))
+		if len(profiles()) > 0 {
+			sections = append(sections, hintStyle.Render("Press esc to pick another profile."))
+		}
+	}
+
 	{
 		if m.err != nil {
 			err := errStyle.Render(m.err.Error())
@@ -66,3 +142,36 @@ func (m model) View() string {
 	content = lipgloss.NewStyle().Width(m.width/2 + 10).Height(m.height / 2).Render(content)
 	return lipgloss.Place(m.width, m.height, lipgloss.Center, lipgloss.Center, content)
 }
+
+// pickerView lists the saved profiles
+func (m model) pickerView() string {
+	sections := []string{titleStyle.Render("jfsh profiles")}
+
+	current := viper.GetString("profile")
+	for i, name := range m.profiles {
+		line := name
+		if host := viper.GetString(profileKey(name, "host")); host != "" {
+			line += " " + hintStyle.UnsetMargins().Render(viper.GetString(profileKey(name, "username"))+"@"+host)
+		}
+		if name == current {
+			line += " " + hintStyle.UnsetMargins().Render("(in use)")
+		}
+		if i == m.cursor {
+			sections = append(sections, currentProfileStyle.Render(line))
+		} else {
+			sections = append(sections, profileStyle.Render(line))
+		}
+	}
+	if len(m.profiles) == 0 {
+		sections = append(sections, hintStyle.Render("No profiles."))
+	}
+
+	sections = append(sections, "", hintStyle.Render("enter switch • a add • e edit • d remove • esc back • q quit"))
+	if m.err != nil {
+		sections = append(sections, errStyle.Render(m.err.Error()))
+	}
+
+	content := lipgloss.JoinVertical(lipgloss.Left, sections...)
+	content = lipgloss.NewStyle().Width(m.width/2 + 10).Height(m.height / 2).Render(content)
+	return lipgloss.Place(m.width, m.height, lipgloss.Center, lipgloss.Center, content)
+}
@@ -0,0 +1,8 @@
+//go:build !unix
+
+package graphics
+
+// cellSize returns the size of a terminal cell in pixels
+func cellSize() (width, height int) {
+	return defaultCellWidth, defaultCellHeight
+}
@@ -0,0 +1,132 @@
+// Package graphics renders images in the terminal with the kitty graphics protocol, sixel or colored half blocks
+package graphics
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"os"
+	"strings"
+)
+
+type Protocol string
+
+const (
+	None      Protocol = "none"
+	Auto      Protocol = "auto"
+	Kitty     Protocol = "kitty"
+	Sixel     Protocol = "sixel"
+	HalfBlock Protocol = "halfblock"
+)
+
+// size of a terminal cell in pixels used when the terminal doesn't report it
+const (
+	defaultCellWidth  = 10
+	defaultCellHeight = 20
+)
+
+// ParseProtocol parses the protocol as written in the config file, defaulting to None
+func ParseProtocol(s string) Protocol {
+	switch p := Protocol(strings.ToLower(s)); p {
+	case Auto:
+		return Detect()
+	case Kitty, Sixel, HalfBlock:
+		return p
+	default:
+		return None
+	}
+}
+
+// Detect guesses the best protocol supported by the terminal from the environment
+func Detect() Protocol {
+	term := os.Getenv("TERM")
+	switch {
+	case os.Getenv("KITTY_WINDOW_ID") != "", term == "xterm-kitty", term == "xterm-ghostty", os.Getenv("TERM_PROGRAM") == "WezTerm":
+		return Kitty
+	case strings.Contains(term, "foot"), strings.Contains(term, "mlterm"), os.Getenv("TERM_PROGRAM") == "iTerm.app":
+		return Sixel
+	default:
+		return HalfBlock
+	}
+}
+
+// Fit returns the size in cells of an image scaled to fit in maxCols by maxRows cells while keeping its aspect ratio
+func Fit(img image.Image, maxCols, maxRows int) (cols, rows int) {
+	b := img.Bounds()
+	if b.Dx() == 0 || b.Dy() == 0 || maxCols <= 0 || maxRows <= 0 {
+		return 0, 0
+	}
+	cellWidth, cellHeight := cellSize()
+	cols = maxCols
+	rows = (b.Dy() * cols * cellWidth) / (b.Dx() * cellHeight)
+	if rows > maxRows {
+		rows = maxRows
+		cols = (b.Dx() * rows * cellHeight) / (b.Dy() * cellWidth)
+	}
+	return max(cols, 1), max(rows, 1)
+}
+
+// resize scales img to width by height pixels by averaging the source pixels covered by each destination pixel
+func resize(img image.Image, width, height int) *image.RGBA {
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	b := img.Bounds()
+	for y := range height {
+		y0 := b.Min.Y + y*b.Dy()/height
+		y1 := max(b.Min.Y+(y+1)*b.Dy()/height, y0+1)
+		for x := range width {
+			x0 := b.Min.X + x*b.Dx()/width
+			x1 := max(b.Min.X+(x+1)*b.Dx()/width, x0+1)
+			var r, g, bl, n uint32
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					cr, cg, cb, _ := img.At(sx, sy).RGBA()
+					r, g, bl, n = r+cr, g+cg, bl+cb, n+1
+				}
+			}
+			dst.SetRGBA(x, y, color.RGBA{uint8(r / n >> 8), uint8(g / n >> 8), uint8(bl / n >> 8), 0xff})
+		}
+	}
+	return dst
+}
+
+// Render returns img drawn in cols by rows cells with protocol p. Half blocks are returned as rows lines of text, while
+// kitty and sixel images are returned as a single escape sequence that draws the image at the cursor, which may move it.
+func Render(p Protocol, img image.Image, cols, rows int) string {
+	if cols <= 0 || rows <= 0 {
+		return ""
+	}
+	switch p {
+	case Kitty:
+		return renderKitty(img, cols, rows)
+	case Sixel:
+		cellWidth, cellHeight := cellSize()
+		return renderSixel(resize(img, cols*cellWidth, rows*cellHeight))
+	case HalfBlock:
+		return renderHalfBlocks(resize(img, cols, rows*2))
+	default:
+		return ""
+	}
+}
+
+// Clear returns an escape sequence that removes images previously drawn with protocol p
+func Clear(p Protocol) string {
+	if p == Kitty {
+		return fmt.Sprintf("\x1b_Ga=d,d=I,i=%d,q=2\x1b\\", kittyImageID)
+	}
+	return ""
+}
+
+func renderHalfBlocks(img *image.RGBA) string {
+	b := img.Bounds()
+	lines := make([]string, 0, b.Dy()/2)
+	for y := b.Min.Y; y+1 < b.Max.Y; y += 2 {
+		line := &strings.Builder{}
+		for x := b.Min.X; x < b.Max.X; x++ {
+			top, bottom := img.RGBAAt(x, y), img.RGBAAt(x, y+1)
+			fmt.Fprintf(line, "\x1b[38;2;%d;%d;%d;48;2;%d;%d;%dm▀", top.R, top.G, top.B, bottom.R, bottom.G, bottom.B)
+		}
+		line.WriteString("\x1b[0m")
+		lines = append(lines, line.String())
+	}
+	return strings.Join(lines, "\n")
+}
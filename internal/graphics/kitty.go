@@ -0,0 +1,40 @@
+package graphics
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"image"
+	"image/png"
+	"strings"
+)
+
+// id of the image jfsh transmits, so that drawing a new image replaces the previous one
+const kittyImageID = 7359
+
+// maximum size of the payload of a single escape sequence
+const kittyChunkSize = 4096
+
+func renderKitty(img image.Image, cols, rows int) string {
+	buf := &bytes.Buffer{}
+	if err := png.Encode(buf, img); err != nil {
+		return ""
+	}
+	data := base64.StdEncoding.EncodeToString(buf.Bytes())
+
+	str := &strings.Builder{}
+	// a=T transmits and displays, C=1 keeps the cursor where it is, q=2 suppresses responses from the terminal
+	for i := 0; i < len(data); i += kittyChunkSize {
+		chunk := data[i:min(i+kittyChunkSize, len(data))]
+		more := 0
+		if i+kittyChunkSize < len(data) {
+			more = 1
+		}
+		if i == 0 {
+			fmt.Fprintf(str, "\x1b_Ga=T,f=100,i=%d,p=1,c=%d,r=%d,C=1,q=2,m=%d;%s\x1b\\", kittyImageID, cols, rows, more, chunk)
+		} else {
+			fmt.Fprintf(str, "\x1b_Gm=%d;%s\x1b\\", more, chunk)
+		}
+	}
+	return str.String()
+}
@@ -0,0 +1,79 @@
+package graphics
+
+import (
+	"fmt"
+	"image"
+	"image/color/palette"
+	"image/draw"
+	"strings"
+)
+
+func renderSixel(img *image.RGBA) string {
+	b := img.Bounds()
+	paletted := image.NewPaletted(b, palette.Plan9)
+	draw.FloydSteinberg.Draw(paletted, b, img, b.Min)
+
+	str := &strings.Builder{}
+	// P2=1 leaves unset pixels transparent
+	str.WriteString("\x1bP0;1q")
+	fmt.Fprintf(str, "\"1;1;%d;%d", b.Dx(), b.Dy())
+	for i, c := range paletted.Palette {
+		r, g, bl, _ := c.RGBA()
+		fmt.Fprintf(str, "#%d;2;%d;%d;%d", i, r*100/0xffff, g*100/0xffff, bl*100/0xffff)
+	}
+
+	// the image is drawn in bands of 6 rows, one pass over the band for each color in it
+	for y := b.Min.Y; y < b.Max.Y; y += 6 {
+		var used [256]bool
+		for dy := range 6 {
+			if y+dy >= b.Max.Y {
+				break
+			}
+			for x := b.Min.X; x < b.Max.X; x++ {
+				used[paletted.ColorIndexAt(x, y+dy)] = true
+			}
+		}
+		first := true
+		for i, ok := range used {
+			if !ok {
+				continue
+			}
+			c := uint8(i)
+			if !first {
+				str.WriteByte('$')
+			}
+			first = false
+			fmt.Fprintf(str, "#%d", c)
+			var run byte
+			count := 0
+			flush := func() {
+				switch {
+				case count == 0:
+				case count > 3:
+					fmt.Fprintf(str, "!%d%c", count, run)
+				default:
+					str.WriteString(strings.Repeat(string(run), count))
+				}
+			}
+			for x := b.Min.X; x < b.Max.X; x++ {
+				var bits byte
+				for dy := range 6 {
+					if y+dy < b.Max.Y && paletted.ColorIndexAt(x, y+dy) == c {
+						bits |= 1 << dy
+					}
+				}
+				ch := '?' + bits
+				if ch == run {
+					count++
+					continue
+				}
+				flush()
+				run, count = ch, 1
+			}
+			flush()
+		}
+		str.WriteByte('-')
+	}
+	str.WriteString("\x1b\\")
+	return str.String()
+}
@@ -3,33 +3,86 @@ package jellyfin
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
+	"net/http"
+	"sync"
 
 	"github.com/sj14/jellyfin-go/api"
 )
 
+// ErrUnauthorized is returned by requests when the server rejected the token and logging in again wasn't possible
+var ErrUnauthorized = errors.New("the server rejected the access token, log in again")
+
 type Client struct {
-	api    *api.APIClient
-	Host   string
-	UserID string
-	Token  string
+	api      *api.APIClient
+	Host     string
+	Username string
+	UserID   string
+	Token    string
+
+	// HTTP is how requests reach the server, players need it to reach the streams too
+	HTTP HTTPOptions
+
+	device, deviceID, version string
+	transport                 http.RoundTripper
+
+	// Password returns the password used to log in again when the server rejects the token. Without it, or if it
+	// returns an empty password, requests fail with ErrUnauthorized instead.
+	Password func() (string, error)
+	// TokenChanged is called with the new token after logging in again, or with an empty one if that wasn't possible
+	TokenChanged func(token string)
+
+	mu       sync.Mutex // guards Token
+	reauthMu sync.Mutex // makes concurrent requests that got rejected log in only once
 }
 
-// get token and user id
-func authorize(host, username, password, device, deviceID, version string) (token, userID string, err error) {
-	authHeader := fmt.Sprintf("MediaBrowser Client=\"jfsh\", Device=%q, DeviceId=%q, Version=%q", device, deviceID, version)
+// authTransport sets the authorization header of every request as it is sent, so that requests retried after
+// logging in again use the new token
+type authTransport struct {
+	header func() string
+	base   http.RoundTripper
+}
+
+func (t *authTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.Header.Set("Authorization", t.header())
+	return t.base.RoundTrip(req)
+}
+
+func authHeader(device, deviceID, version, token string) string {
+	header := fmt.Sprintf("MediaBrowser Client=\"jfsh\", Device=%q, DeviceId=%q, Version=%q", device, deviceID, version)
+	if token != "" {
+		header += fmt.Sprintf(", Token=%q", token)
+	}
+	return header
+}
+
+// newAPIClient returns an api client identifying itself as jfsh with the authorization header returned by header
+func newAPIClient(host string, transport http.RoundTripper, header func() string) *api.APIClient {
 	config := &api.Configuration{
-		Servers:       api.ServerConfigurations{{URL: host}},
-		DefaultHeader: map[string]string{"Authorization": authHeader},
+		Servers:    api.ServerConfigurations{{URL: host}},
+		HTTPClient: &http.Client{Transport: &authTransport{header: header, base: transport}},
 	}
-	cl := api.NewAPIClient(config)
-	res, _, err := cl.UserAPI.AuthenticateUserByName(context.Background()).AuthenticateUserByName(api.AuthenticateUserByName{
+	return api.NewAPIClient(config)
+}
+
+// newAnonymousAPIClient returns an api client for the requests made before having a token
+func newAnonymousAPIClient(host, device, deviceID, version string, transport http.RoundTripper) *api.APIClient {
+	return newAPIClient(host, transport, func() string { return authHeader(device, deviceID, version, "") })
+}
+
+// get token and user id
+func authorize(host, username, password, device, deviceID, version string, transport http.RoundTripper) (token, userID string, err error) {
+	cl := newAnonymousAPIClient(host, device, deviceID, version, transport)
+	res, httpRes, err := cl.UserAPI.AuthenticateUserByName(context.Background()).AuthenticateUserByName(api.AuthenticateUserByName{
 		Username: *api.NewNullableString(&username),
 		Pw:       *api.NewNullableString(&password),
 	}).Execute()
 	if err != nil {
 		slog.Error("failed to authenticate", "err", err)
+		err = describeLoginError(httpRes, err)
 		return
 	}
 	token = *res.AccessToken.Get()
@@ -37,9 +90,13 @@ func authorize(host, username, password, device, deviceID, version string) (toke
 	return
 }
 
-func NewClient(host, username, password, device, deviceID, version, token, userID string) (*Client, error) {
+func NewClient(host, username, password, device, deviceID, version, token, userID string, opts HTTPOptions) (*Client, error) {
+	transport, err := newTransport(opts)
+	if err != nil {
+		return nil, err
+	}
 	if token == "" || userID == "" {
-		newToken, newUserID, err := authorize(host, username, password, device, deviceID, version)
+		newToken, newUserID, err := authorize(host, username, password, device, deviceID, version, transport)
 		if err != nil {
 			return nil, err
 		}
@@ -47,11 +104,136 @@ func NewClient(host, username, password, device, deviceID, version, token, userI
 		userID = newUserID
 	}
 
-	authHeader := fmt.Sprintf("MediaBrowser Client=\"jfsh\", Device=%q, DeviceId=%q, Version=%q, Token=%q", device, deviceID, version, token)
-	config := &api.Configuration{
-		Servers:       api.ServerConfigurations{{URL: host}},
-		DefaultHeader: map[string]string{"Authorization": authHeader},
+	c := &Client{Host: host, Username: username, UserID: userID, Token: token, HTTP: opts, device: device, deviceID: deviceID, version: version, transport: transport}
+	c.api = newAPIClient(host, transport, func() string { return authHeader(device, deviceID, version, c.token()) })
+	return c, nil
+}
+
+func (c *Client) token() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.Token
+}
+
+// reauthenticate logs in again after the server rejected rejectedToken
+func (c *Client) reauthenticate(rejectedToken string) error {
+	c.reauthMu.Lock()
+	defer c.reauthMu.Unlock()
+	if c.token() != rejectedToken {
+		// another request already logged in again
+		return nil
+	}
+	var password string
+	if c.Password != nil {
+		var err error
+		if password, err = c.Password(); err != nil {
+			return fmt.Errorf("%w: %w", ErrUnauthorized, err)
+		}
+	}
+	if password == "" {
+		c.setToken("")
+		return ErrUnauthorized
+	}
+	slog.Info("token rejected, logging in again")
+	token, _, err := authorize(c.Host, c.Username, password, c.device, c.deviceID, c.version, c.transport)
+	if err != nil {
+		c.setToken("")
+		return fmt.Errorf("%w: %w", ErrUnauthorized, err)
+	}
+	c.setToken(token)
+	return nil
+}
+
+func (c *Client) setToken(token string) {
+	c.mu.Lock()
+	c.Token = token
+	c.mu.Unlock()
+	if c.TokenChanged != nil {
+		c.TokenChanged(token)
+	}
+}
+
+// call runs a request, logging in again and retrying it once if the server rejects the token
+func call[T any](c *Client, execute func() (T, *http.Response, error)) (T, error) {
+	token := c.token()
+	res, httpRes, err := execute()
+	if err == nil || httpRes == nil || httpRes.StatusCode != http.StatusUnauthorized {
+		return res, err
+	}
+	if err := c.reauthenticate(token); err != nil {
+		var zero T
+		return zero, err
+	}
+	res, _, err = execute()
+	return res, err
+}
+
+// do is call for requests without a response body
+func do(c *Client, execute func() (*http.Response, error)) error {
+	_, err := call(c, func() (struct{}, *http.Response, error) {
+		httpRes, err := execute()
+		return struct{}{}, httpRes, err
+	})
+	return err
+}
+
+// QuickConnect is a pending Quick Connect login, authorized once the user enters Code in another signed in client
+type QuickConnect struct {
+	Code   string
+	secret string
+
+	api                             *api.APIClient
+	host, device, deviceID, version string
+	opts                            HTTPOptions
+}
+
+// InitiateQuickConnect starts a Quick Connect login
+func InitiateQuickConnect(host, device, deviceID, version string, opts HTTPOptions) (*QuickConnect, error) {
+	transport, err := newTransport(opts)
+	if err != nil {
+		return nil, err
+	}
+	cl := newAnonymousAPIClient(host, device, deviceID, version, transport)
+	enabled, _, err := cl.QuickConnectAPI.GetQuickConnectEnabled(context.Background()).Execute()
+	if err != nil {
+		return nil, err
+	}
+	if !enabled {
+		return nil, errors.New("quick connect is disabled on the server")
+	}
+	res, _, err := cl.QuickConnectAPI.InitiateQuickConnect(context.Background()).Execute()
+	if err != nil {
+		return nil, err
+	}
+	return &QuickConnect{
+		Code:     res.GetCode(),
+		secret:   res.GetSecret(),
+		api:      cl,
+		host:     host,
+		device:   device,
+		deviceID: deviceID,
+		version:  version,
+		opts:     opts,
+	}, nil
+}
+
+// Authorized reports whether the user has entered the code yet
+func (q *QuickConnect) Authorized() (bool, error) {
+	res, _, err := q.api.QuickConnectAPI.GetQuickConnectState(context.Background()).Secret(q.secret).Execute()
+	if err != nil {
+		return false, err
+	}
+	return res.GetAuthenticated(), nil
+}
+
+// NewClient exchanges an authorized Quick Connect login for a client
+func (q *QuickConnect) NewClient() (*Client, error) {
+	res, _, err := q.api.UserAPI.AuthenticateWithQuickConnect(context.Background()).
+		QuickConnectDto(api.QuickConnectDto{Secret: q.secret}).
+		Execute()
+	if err != nil {
+		return nil, err
 	}
-	apiClient := api.NewAPIClient(config)
-	return &Client{api: apiClient, Host: host, UserID: userID, Token: token}, nil
+	user := res.GetUser()
+	return NewClient(q.host, user.GetName(), "", q.device, q.deviceID, q.version, res.GetAccessToken(), user.GetId(), q.opts)
 }
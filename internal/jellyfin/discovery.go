@@ -0,0 +1,58 @@
+package jellyfin
+
+import (
+	"encoding/json"
+	"errors"
+	"net"
+	"os"
+	"time"
+)
+
+// port servers listen on for discovery broadcasts
+const discoveryPort = 7359
+
+// Server is a server found on the local network
+type Server struct {
+	Address string `json:"Address"`
+	ID      string `json:"Id"`
+	Name    string `json:"Name"`
+}
+
+// Discover broadcasts a discovery message on the local network and returns the servers that answer within timeout
+func Discover(timeout time.Duration) ([]Server, error) {
+	conn, err := net.ListenUDP("udp4", nil)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	if _, err := conn.WriteToUDP([]byte("who is JellyfinServer?"), &net.UDPAddr{IP: net.IPv4bcast, Port: discoveryPort}); err != nil {
+		return nil, err
+	}
+	if err := conn.SetReadDeadline(time.Now().Add(timeout)); err != nil {
+		return nil, err
+	}
+
+	var servers []Server
+	seen := make(map[string]bool)
+	buf := make([]byte, 4096)
+	for {
+		n, _, err := conn.ReadFromUDP(buf)
+		if errors.Is(err, os.ErrDeadlineExceeded) {
+			return servers, nil
+		}
+		if err != nil {
+			return servers, err
+		}
+		var server Server
+		if err := json.Unmarshal(buf[:n], &server); err != nil || server.Address == "" {
+			// not a jellyfin server
+			continue
+		}
+		if seen[server.ID] {
+			continue
+		}
+		seen[server.ID] = true
+		servers = append(servers, server)
+	}
+}
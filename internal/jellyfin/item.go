@@ -30,9 +30,14 @@ func GetResumePosition(item Item) (ticks int64) {
 	return
 }
 
-func GetStreamingURL(host string, item Item) string {
-	url := fmt.Sprintf("%s/videos/%s/stream?maxWidth=854&maxHeight=480&videoBitRate=1500000", host, *item.Id)
-	return fmt.Sprintf("edl://%%%d%%%s", len(url), url)
+// WithoutResumePosition returns a copy of item that plays from the start
+func WithoutResumePosition(item Item) Item {
+	if data, ok := item.GetUserDataOk(); ok {
+		data := *data
+		data.SetPlaybackPositionTicks(0)
+		item.SetUserData(data)
+	}
+	return item
 }
 
 func GetMediaTitle(item Item) string {
@@ -64,8 +69,23 @@ func GetItemTitle(item Item) string {
 		if data, ok := item.GetUserDataOk(); ok {
 			fmt.Fprintf(str, " [%d]", data.GetUnplayedItemCount())
 		}
+	case api.BASEITEMKIND_SEASON:
+		fmt.Fprintf(str, "%s", item.GetName())
+		if data, ok := item.GetUserDataOk(); ok {
+			fmt.Fprintf(str, " [%d]", data.GetUnplayedItemCount())
+		}
 	case api.BASEITEMKIND_VIDEO:
 		fmt.Fprintf(str, "%s (%d)", item.GetName(), item.GetProductionYear())
+	case api.BASEITEMKIND_COLLECTION_FOLDER, api.BASEITEMKIND_USER_VIEW, api.BASEITEMKIND_FOLDER:
+		fmt.Fprintf(str, "%s", item.GetName())
+	case api.BASEITEMKIND_BOX_SET:
+		fmt.Fprintf(str, "%s", item.GetName())
+		if count, ok := item.GetChildCountOk(); ok && count != nil {
+			fmt.Fprintf(str, " [%d]", *count)
+		}
+	}
+	if Favorite(item) {
+		str.WriteString(" ♥")
 	}
 	return str.String()
 }
@@ -77,14 +97,74 @@ func GetItemDescription(item Item) string {
 		fmt.Fprintf(str, "Movie  | Rating: %.1f | Runtime: %s", item.GetCommunityRating(), getItemRuntime(item.GetRunTimeTicks()))
 	case api.BASEITEMKIND_SERIES:
 		fmt.Fprintf(str, "Series | Rating: %.1f", item.GetCommunityRating())
+	case api.BASEITEMKIND_SEASON:
+		fmt.Fprintf(str, "Season | Episodes: %d", item.GetChildCount())
 	case api.BASEITEMKIND_EPISODE:
 		fmt.Fprintf(str, "%s", item.GetName())
 	case api.BASEITEMKIND_VIDEO:
 		fmt.Fprintf(str, "Video  | Rating: %.1f | Runtime: %s", item.GetCommunityRating(), getItemRuntime(item.GetRunTimeTicks()))
+	case api.BASEITEMKIND_COLLECTION_FOLDER, api.BASEITEMKIND_USER_VIEW, api.BASEITEMKIND_FOLDER:
+		str.WriteString("Library")
+	case api.BASEITEMKIND_BOX_SET:
+		str.WriteString("Collection")
 	}
 	return str.String()
 }
 
+// GetItemCast returns the actors of an item as "Name as Role"
+func GetItemCast(item Item) []string {
+	var cast []string
+	for _, person := range item.GetPeople() {
+		if person.GetType() != api.PERSONKIND_ACTOR && person.GetType() != api.PERSONKIND_GUEST_STAR {
+			continue
+		}
+		if role := person.GetRole(); role != "" {
+			cast = append(cast, fmt.Sprintf("%s as %s", person.GetName(), role))
+		} else {
+			cast = append(cast, person.GetName())
+		}
+	}
+	return cast
+}
+
+// GetItemCrew returns the directors and writers of an item as "Name (Type)"
+func GetItemCrew(item Item) []string {
+	var crew []string
+	for _, person := range item.GetPeople() {
+		if person.GetType() != api.PERSONKIND_DIRECTOR && person.GetType() != api.PERSONKIND_WRITER {
+			continue
+		}
+		crew = append(crew, fmt.Sprintf("%s (%s)", person.GetName(), person.GetType()))
+	}
+	return crew
+}
+
+func GetItemStudios(item Item) []string {
+	var studios []string
+	for _, studio := range item.GetStudios() {
+		studios = append(studios, studio.GetName())
+	}
+	return studios
+}
+
+// GetItemMediaInfo returns a line for each video, audio and subtitle stream of an item, e.g. "Audio: English - AAC - Stereo"
+func GetItemMediaInfo(item Item) []string {
+	var info []string
+	for _, stream := range item.GetMediaStreams() {
+		switch stream.GetType() {
+		case api.MEDIASTREAMTYPE_VIDEO, api.MEDIASTREAMTYPE_AUDIO, api.MEDIASTREAMTYPE_SUBTITLE:
+		default:
+			continue
+		}
+		line := fmt.Sprintf("%s: %s", stream.GetType(), stream.GetDisplayTitle())
+		if stream.GetIsExternal() {
+			line += " (External)"
+		}
+		info = append(info, line)
+	}
+	return info
+}
+
 func IsMovie(item Item) bool {
 	return item.GetType() == api.BASEITEMKIND_MOVIE
 }
@@ -93,6 +173,10 @@ func IsSeries(item Item) bool {
 	return item.GetType() == api.BASEITEMKIND_SERIES
 }
 
+func IsSeason(item Item) bool {
+	return item.GetType() == api.BASEITEMKIND_SEASON
+}
+
 func IsEpisode(item Item) bool {
 	return item.GetType() == api.BASEITEMKIND_EPISODE
 }
@@ -101,6 +185,15 @@ func IsVideo(item Item) bool {
 	return item.GetType() == api.BASEITEMKIND_VIDEO
 }
 
+// IsLibrary reports whether item is a library, folder or collection whose contents can be listed with GetLibraryItems
+func IsLibrary(item Item) bool {
+	switch item.GetType() {
+	case api.BASEITEMKIND_COLLECTION_FOLDER, api.BASEITEMKIND_USER_VIEW, api.BASEITEMKIND_FOLDER, api.BASEITEMKIND_BOX_SET:
+		return true
+	}
+	return false
+}
+
 func Watched(item Item) bool {
 	if data, ok := item.GetUserDataOk(); ok {
 		return data.GetPlayed()
@@ -108,21 +201,87 @@ func Watched(item Item) bool {
 	return false
 }
 
+// Image identifies an image of an item on the server
+type Image struct {
+	ItemID string
+	Type   string
+	Tag    string
+}
+
+// GetItemImage returns the poster of item, falling back to its thumbnail and then to the images of its series or parent
+func GetItemImage(item Item) (Image, bool) {
+	tags := item.GetImageTags()
+	for _, t := range []api.ImageType{api.IMAGETYPE_PRIMARY, api.IMAGETYPE_THUMB} {
+		if tag, ok := tags[string(t)]; ok {
+			return Image{ItemID: item.GetId(), Type: string(t), Tag: tag}, true
+		}
+	}
+	if tag := item.GetSeriesPrimaryImageTag(); tag != "" && item.GetSeriesId() != "" {
+		return Image{ItemID: item.GetSeriesId(), Type: string(api.IMAGETYPE_PRIMARY), Tag: tag}, true
+	}
+	if tag := item.GetParentThumbImageTag(); tag != "" && item.GetParentThumbItemId() != "" {
+		return Image{ItemID: item.GetParentThumbItemId(), Type: string(api.IMAGETYPE_THUMB), Tag: tag}, true
+	}
+	return Image{}, false
+}
+
+func Favorite(item Item) bool {
+	if data, ok := item.GetUserDataOk(); ok {
+		return data.GetIsFavorite()
+	}
+	return false
+}
+
+// MediaSource is a version of an item, e.g. a 4K and a 1080p file of the same movie
+type MediaSource = api.MediaSourceInfo
+
+// HasMultipleVersions reports whether item has more than one media source to pick from
+func HasMultipleVersions(item Item) bool {
+	return len(item.GetMediaSources()) > 1 || item.GetMediaSourceCount() > 1
+}
+
+// GetMediaSourceDescription describes source by its name, resolution, video codec and size
+func GetMediaSourceDescription(source MediaSource) string {
+	parts := []string{source.GetName()}
+	for _, stream := range source.GetMediaStreams() {
+		if stream.GetType() != api.MEDIASTREAMTYPE_VIDEO {
+			continue
+		}
+		video := strings.ToUpper(stream.GetCodec())
+		if height := stream.GetHeight(); height > 0 {
+			video = fmt.Sprintf("%dp %s", height, video)
+		}
+		parts = append(parts, video)
+		break
+	}
+	if size := source.GetSize(); size > 0 {
+		parts = append(parts, fmt.Sprintf("%.1f GB", float64(size)/1e9))
+	}
+	return strings.Join(parts, " | ")
+}
+
 // ExternalSubtitleStream represents an external subtitle stream
 type ExternalSubtitleStream struct {
+	Index    int32
 	Language string
 	Title    string
 	Path     string
 }
 
-// GetExternalSubtitleStreams returns all external subtitle streams for an item
-func GetExternalSubtitleStreams(item Item) []ExternalSubtitleStream {
+// GetExternalSubtitleStreams returns all external subtitle streams of the media source played for an item, the ones of
+// its default media source if it is unknown
+func GetExternalSubtitleStreams(item Item, source MediaSource) []ExternalSubtitleStream {
 	var subtitles []ExternalSubtitleStream
-	streams := item.GetMediaStreams()
+	streams := source.GetMediaStreams()
+	sourceID := source.GetId()
+	if len(streams) == 0 {
+		streams = item.GetMediaStreams()
+		sourceID = item.GetId()
+	}
 	for _, stream := range streams {
 		if stream.GetType() == "Subtitle" && stream.GetIsExternal() {
 			index := stream.GetIndex()
-			subtitle := ExternalSubtitleStream{}
+			subtitle := ExternalSubtitleStream{Index: index}
 			if lang, ok := stream.GetLanguageOk(); ok && lang != nil {
 				subtitle.Language = *lang
 			}
@@ -131,7 +290,7 @@ func GetExternalSubtitleStreams(item Item) []ExternalSubtitleStream {
 			} else {
 				subtitle.Title = fmt.Sprintf("External %d", index)
 			}
-			subtitle.Path = fmt.Sprintf("/Videos/%s/%s/Subtitles/%d/0/Stream.srt", item.GetId(), item.GetId(), index)
+			subtitle.Path = fmt.Sprintf("/Videos/%s/%s/Subtitles/%d/0/Stream.srt", item.GetId(), sourceID, index)
 			subtitles = append(subtitles, subtitle)
 		}
 	}
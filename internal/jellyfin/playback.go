@@ -0,0 +1,265 @@
+package jellyfin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/sj14/jellyfin-go/api"
+)
+
+// PlaybackOptions are what the server is told about the player when asked how to play an item
+type PlaybackOptions struct {
+	// Transcode makes the server transcode even when the file could be played as is
+	Transcode bool
+	// MaxWidth and MaxHeight cap the resolution, 0 for no limit
+	MaxWidth, MaxHeight int
+	// MaxBitrate caps the bitrate in bits per second, 0 for the limit of the user on the server
+	MaxBitrate int
+	// VideoCodecs and AudioCodecs are the codecs played as is and transcoded to, in order of preference. Any codec is
+	// played as is if empty, and transcoded to h264 and aac.
+	VideoCodecs, AudioCodecs []string
+	// AudioChannels caps the number of audio channels, 0 for no limit
+	AudioChannels int
+	// Tracks picks the audio and subtitle tracks
+	Tracks TrackPreferences
+}
+
+// Limited reports whether opts ask for anything but the file as it's stored on the server
+func (opts PlaybackOptions) Limited() bool {
+	return opts.Transcode || opts.MaxWidth > 0 || opts.MaxHeight > 0 || opts.MaxBitrate > 0 ||
+		len(opts.VideoCodecs) > 0 || len(opts.AudioCodecs) > 0 || opts.AudioChannels > 0
+}
+
+// Stream is how to play an item
+type Stream struct {
+	URL           string
+	MediaSourceID string
+	PlaySessionID string
+	PlayMethod    api.PlayMethod
+	// Source is the media source played, empty if the server wasn't asked
+	Source MediaSource
+	// AudioStreamIndex and SubtitleStreamIndex are the streams of Source picked by the track preferences, NoTrack if
+	// none was
+	AudioStreamIndex, SubtitleStreamIndex int32
+}
+
+// PlayerState is the state of the player reported along with the position
+type PlayerState struct {
+	Paused, Muted bool
+	// Volume goes from 0 to 100
+	Volume int
+}
+
+// containers mpv plays as is. Codecs aren't listed since mpv decodes nearly everything ffmpeg does.
+const directPlayContainers = "mkv,webm,mp4,m4v,mov,avi,wmv,asf,flv,ts,m2ts,mpegts,mpg,mpeg,vob,ogv,3gp"
+
+// deviceProfile describes mpv, limited by opts, to the server, which transcodes what it can't play
+func deviceProfile(opts PlaybackOptions) api.DeviceProfile {
+	directPlay := api.DirectPlayProfile{
+		Container: api.PtrString(directPlayContainers),
+		Type:      api.DLNAPROFILETYPE_VIDEO.Ptr(),
+	}
+	transcoding := api.TranscodingProfile{
+		Container:  api.PtrString("ts"),
+		Type:       api.DLNAPROFILETYPE_VIDEO.Ptr(),
+		VideoCodec: api.PtrString("h264"),
+		AudioCodec: api.PtrString("aac,mp3,ac3"),
+		Protocol:   api.MEDIASTREAMPROTOCOL_HLS.Ptr(),
+		Context:    api.ENCODINGCONTEXT_STREAMING.Ptr(),
+	}
+	if len(opts.VideoCodecs) > 0 {
+		codecs := strings.Join(opts.VideoCodecs, ",")
+		directPlay.VideoCodec = *api.NewNullableString(&codecs)
+		transcoding.VideoCodec = &codecs
+	}
+	if len(opts.AudioCodecs) > 0 {
+		codecs := strings.Join(opts.AudioCodecs, ",")
+		directPlay.AudioCodec = *api.NewNullableString(&codecs)
+		transcoding.AudioCodec = &codecs
+	}
+	if opts.AudioChannels > 0 {
+		channels := strconv.Itoa(opts.AudioChannels)
+		transcoding.MaxAudioChannels = *api.NewNullableString(&channels)
+	}
+	profile := api.DeviceProfile{
+		Name:                *api.NewNullableString(api.PtrString("jfsh")),
+		DirectPlayProfiles:  []api.DirectPlayProfile{directPlay},
+		TranscodingProfiles: []api.TranscodingProfile{transcoding},
+	}
+	if opts.MaxBitrate > 0 {
+		bitrate := int32(opts.MaxBitrate)
+		profile.MaxStreamingBitrate = *api.NewNullableInt32(&bitrate)
+		profile.MaxStaticBitrate = *api.NewNullableInt32(&bitrate)
+	}
+	var video []api.ProfileCondition
+	if opts.MaxWidth > 0 {
+		video = append(video, lessThanEqual(api.PROFILECONDITIONVALUE_WIDTH, opts.MaxWidth))
+	}
+	if opts.MaxHeight > 0 {
+		video = append(video, lessThanEqual(api.PROFILECONDITIONVALUE_HEIGHT, opts.MaxHeight))
+	}
+	if len(video) > 0 {
+		profile.CodecProfiles = append(profile.CodecProfiles, api.CodecProfile{Type: api.CODECTYPE_VIDEO.Ptr(), Conditions: video})
+	}
+	if opts.AudioChannels > 0 {
+		profile.CodecProfiles = append(profile.CodecProfiles, api.CodecProfile{
+			Type:       api.CODECTYPE_VIDEO_AUDIO.Ptr(),
+			Conditions: []api.ProfileCondition{lessThanEqual(api.PROFILECONDITIONVALUE_AUDIO_CHANNELS, opts.AudioChannels)},
+		})
+	}
+	return profile
+}
+
+func lessThanEqual(property api.ProfileConditionValue, value int) api.ProfileCondition {
+	return api.ProfileCondition{
+		Condition:  api.PROFILECONDITIONTYPE_LESS_THAN_EQUAL.Ptr(),
+		Property:   &property,
+		Value:      *api.NewNullableString(api.PtrString(strconv.Itoa(value))),
+		IsRequired: api.PtrBool(true),
+	}
+}
+
+// GetStream asks the server how to play the media source of item with id mediaSourceID, or its default one if empty. It
+// is played as is unless the server or opts require a transcode.
+func (c *Client) GetStream(item Item, mediaSourceID string, opts PlaybackOptions) (Stream, error) {
+	profile := deviceProfile(opts)
+	info := api.PlaybackInfoDto{
+		UserId:             *api.NewNullableString(&c.UserID),
+		DeviceProfile:      *api.NewNullableDeviceProfile(&profile),
+		EnableDirectPlay:   *api.NewNullableBool(api.PtrBool(!opts.Transcode)),
+		EnableDirectStream: *api.NewNullableBool(api.PtrBool(!opts.Transcode)),
+		EnableTranscoding:  *api.NewNullableBool(api.PtrBool(true)),
+		MediaSourceId:      nullableString(mediaSourceID),
+	}
+	if opts.MaxBitrate > 0 {
+		info.MaxStreamingBitrate = *api.NewNullableInt32(api.PtrInt32(int32(opts.MaxBitrate)))
+	}
+	if opts.AudioChannels > 0 {
+		info.MaxAudioChannels = *api.NewNullableInt32(api.PtrInt32(int32(opts.AudioChannels)))
+	}
+	res, err := call(c, c.api.MediaInfoAPI.GetPostedPlaybackInfo(context.Background(), item.GetId()).
+		PlaybackInfoDto(info).
+		Execute)
+	if err != nil {
+		return Stream{}, err
+	}
+	if code, ok := res.GetErrorCodeOk(); ok && code != nil {
+		return Stream{}, fmt.Errorf("server can't play %q: %s", item.GetName(), *code)
+	}
+	if len(res.MediaSources) == 0 {
+		return Stream{}, fmt.Errorf("%q has no media sources", item.GetName())
+	}
+	source := res.MediaSources[0]
+	for _, s := range res.MediaSources {
+		if s.GetId() == mediaSourceID {
+			source = s
+		}
+	}
+	stream := Stream{MediaSourceID: source.GetId(), PlaySessionID: res.GetPlaySessionId(), Source: source}
+	stream.AudioStreamIndex, stream.SubtitleStreamIndex = selectTracks(source.GetMediaStreams(), opts.Tracks)
+	switch {
+	case !opts.Transcode && source.GetSupportsDirectPlay():
+		stream.PlayMethod = api.PLAYMETHOD_DIRECT_PLAY
+		stream.URL = staticURL(c.Host, item.GetId(), stream.MediaSourceID, stream.PlaySessionID)
+	case !opts.Transcode && source.GetSupportsDirectStream():
+		// mpv plays any container, so streaming the file as is works as well as remuxing it
+		stream.PlayMethod = api.PLAYMETHOD_DIRECT_STREAM
+		stream.URL = staticURL(c.Host, item.GetId(), stream.MediaSourceID, stream.PlaySessionID)
+	case source.GetTranscodingUrl() != "":
+		stream.PlayMethod = api.PLAYMETHOD_TRANSCODE
+		transcodingURL, err := withTracks(source.GetTranscodingUrl(), stream)
+		if err != nil {
+			return Stream{}, err
+		}
+		stream.URL = edl(c.Host + transcodingURL)
+	default:
+		return Stream{}, errors.New("server offered no way to play " + item.GetName())
+	}
+	return stream, nil
+}
+
+// StaticStream plays the default media source of item as is, without asking the server
+func StaticStream(host string, item Item) Stream {
+	return Stream{
+		URL:        staticURL(host, item.GetId(), "", ""),
+		PlayMethod: api.PLAYMETHOD_DIRECT_PLAY,
+		// the server only hands out play sessions when asked, but reports of the same playback still need one
+		PlaySessionID:       strings.ReplaceAll(uuid.NewString(), "-", ""),
+		AudioStreamIndex:    NoTrack,
+		SubtitleStreamIndex: NoTrack,
+	}
+}
+
+// withTracks makes the transcode at transcodingURL use the tracks picked for stream, burning in embedded subtitles
+// since they can't be picked from the transcode
+func withTracks(transcodingURL string, stream Stream) (string, error) {
+	u, err := url.Parse(transcodingURL)
+	if err != nil {
+		return "", err
+	}
+	query := u.Query()
+	if stream.AudioStreamIndex != NoTrack {
+		query.Set("AudioStreamIndex", strconv.Itoa(int(stream.AudioStreamIndex)))
+	}
+	query.Del("SubtitleStreamIndex")
+	query.Del("SubtitleMethod")
+	if subtitle, ok := stream.GetStream(stream.SubtitleStreamIndex); ok && !subtitle.GetIsExternal() {
+		query.Set("SubtitleStreamIndex", strconv.Itoa(int(stream.SubtitleStreamIndex)))
+		query.Set("SubtitleMethod", "Encode")
+	}
+	u.RawQuery = query.Encode()
+	return u.String(), nil
+}
+
+// GetStream returns the stream of the media source with index
+func (s Stream) GetStream(index int32) (api.MediaStream, bool) {
+	i := slices.IndexFunc(s.Source.GetMediaStreams(), func(stream api.MediaStream) bool { return stream.GetIndex() == index })
+	if i < 0 {
+		return api.MediaStream{}, false
+	}
+	return s.Source.GetMediaStreams()[i], true
+}
+
+// streamIndices returns the indices of the audio and subtitle streams to report, null when the tracks are unknown. The
+// server takes a subtitle index of -1 for subtitles turned off.
+func (s Stream) streamIndices() (audio, subtitle api.NullableInt32) {
+	audio, subtitle = *api.NewNullableInt32(nil), *api.NewNullableInt32(nil)
+	if len(s.Source.GetMediaStreams()) == 0 {
+		return audio, subtitle
+	}
+	if s.AudioStreamIndex != NoTrack {
+		audio.Set(api.PtrInt32(s.AudioStreamIndex))
+	}
+	subtitle.Set(api.PtrInt32(s.SubtitleStreamIndex))
+	return audio, subtitle
+}
+
+func staticURL(host, itemID, mediaSourceID, playSessionID string) string {
+	query := url.Values{"static": {"true"}}
+	if mediaSourceID != "" {
+		query.Set("mediaSourceId", mediaSourceID)
+	}
+	if playSessionID != "" {
+		query.Set("playSessionId", playSessionID)
+	}
+	return edl(fmt.Sprintf("%s/Videos/%s/stream?%s", host, itemID, query.Encode()))
+}
+
+// nullableString returns a null string if s is empty
+func nullableString(s string) api.NullableString {
+	if s == "" {
+		return *api.NewNullableString(nil)
+	}
+	return *api.NewNullableString(&s)
+}
+
+// edl wraps url in an edl playlist so that mpv takes it as is
+func edl(url string) string {
+	return fmt.Sprintf("edl://%%%d%%%s", len(url), url)
+}
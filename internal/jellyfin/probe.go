@@ -0,0 +1,125 @@
+package jellyfin
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// ErrBadCredentials is returned when the server rejects the username and password
+var ErrBadCredentials = errors.New("wrong username or password")
+
+// oldest server version jfsh works with
+const minServerVersion = "10.8.0"
+
+// how long to wait for the server to answer a probe
+const probeTimeout = 10 * time.Second
+
+// ServerInfo is what a server tells about itself before logging in
+type ServerInfo struct {
+	ID      string
+	Name    string
+	Version string
+}
+
+// Probe checks that host is a Jellyfin server jfsh can talk to. Failures are turned into errors that tell the user what
+// to fix.
+func Probe(host, device, deviceID, version string, opts HTTPOptions) (ServerInfo, error) {
+	transport, err := newTransport(opts)
+	if err != nil {
+		return ServerInfo{}, err
+	}
+	cl := newAnonymousAPIClient(host, device, deviceID, version, transport)
+	ctx, cancel := context.WithTimeout(context.Background(), probeTimeout)
+	defer cancel()
+	res, httpRes, err := cl.SystemAPI.GetPublicSystemInfo(ctx).Execute()
+	if err != nil {
+		if httpRes == nil {
+			return ServerInfo{}, describeConnectionError(err)
+		}
+		switch code := httpRes.StatusCode; {
+		case code == http.StatusUnauthorized || code == http.StatusForbidden || code == http.StatusProxyAuthRequired:
+			// the public system info never requires a login, so this is a proxy or firewall in front of the server
+			return ServerInfo{}, fmt.Errorf("the server or a proxy in front of it requires authentication (%s), check the headers in the profile", httpRes.Status)
+		case code >= http.StatusInternalServerError:
+			// e.g. a reverse proxy that can't reach the server, which may well be a Jellyfin server
+			return ServerInfo{}, fmt.Errorf("server error (%s), check that the server is running", httpRes.Status)
+		}
+		return ServerInfo{}, fmt.Errorf("not a Jellyfin server (%s), check the address", httpRes.Status)
+	}
+	if res.GetId() == "" || !strings.HasPrefix(res.GetProductName(), "Jellyfin") {
+		return ServerInfo{}, errors.New("not a Jellyfin server, check the address")
+	}
+	info := ServerInfo{ID: res.GetId(), Name: res.GetServerName(), Version: res.GetVersion()}
+	if compareVersions(info.Version, minServerVersion) < 0 {
+		return info, fmt.Errorf("Jellyfin %s is too old, jfsh needs %s or newer", info.Version, minServerVersion)
+	}
+	return info, nil
+}
+
+// describeConnectionError explains why a request couldn't reach the server
+func describeConnectionError(err error) error {
+	var dnsErr *net.DNSError
+	var recordErr tls.RecordHeaderError
+	var certErr *tls.CertificateVerificationError
+	var authorityErr x509.UnknownAuthorityError
+	var hostnameErr x509.HostnameError
+	var invalidErr x509.CertificateInvalidError
+	var netErr net.Error
+	switch {
+	case errors.As(err, &dnsErr):
+		return fmt.Errorf("host %q not found, check the address", dnsErr.Name)
+	case errors.Is(err, syscall.ECONNREFUSED):
+		return errors.New("connection refused, check the port and that the server is running")
+	case errors.As(err, &recordErr):
+		return errors.New("the server doesn't use TLS, try http:// instead of https://")
+	case errors.As(err, &hostnameErr):
+		return fmt.Errorf("the TLS certificate isn't valid for this host: %w", hostnameErr)
+	case errors.As(err, &authorityErr):
+		return errors.New("the TLS certificate isn't signed by a trusted authority, set tls.ca_file in the profile")
+	case errors.As(err, &invalidErr):
+		return fmt.Errorf("the TLS certificate is invalid: %w", invalidErr)
+	case errors.As(err, &certErr):
+		return fmt.Errorf("the TLS certificate couldn't be verified: %w", certErr.Err)
+	case errors.As(err, &netErr) && netErr.Timeout():
+		return errors.New("the server didn't answer in time")
+	}
+	return err
+}
+
+// describeLoginError explains why logging in failed
+func describeLoginError(httpRes *http.Response, err error) error {
+	if httpRes == nil {
+		return describeConnectionError(err)
+	}
+	if httpRes.StatusCode == http.StatusUnauthorized {
+		return ErrBadCredentials
+	}
+	return err
+}
+
+// compareVersions compares dotted version numbers like 10.10.7, missing or invalid parts counting as 0
+func compareVersions(a, b string) int {
+	as, bs := strings.Split(a, "."), strings.Split(b, ".")
+	for i := range max(len(as), len(bs)) {
+		var x, y int
+		if i < len(as) {
+			x, _ = strconv.Atoi(as[i])
+		}
+		if i < len(bs) {
+			y, _ = strconv.Atoi(bs[i])
+		}
+		if x != y {
+			return x - y
+		}
+	}
+	return 0
+}
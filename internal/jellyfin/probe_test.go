@@ -0,0 +1,46 @@
+package jellyfin
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestProbe(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		body    string
+		wantErr string
+	}{
+		{"jellyfin", http.StatusOK, `{"Id":"abc","ServerName":"home","Version":"10.10.7","ProductName":"Jellyfin Server"}`, ""},
+		{"too old", http.StatusOK, `{"Id":"abc","ServerName":"home","Version":"10.7.7","ProductName":"Jellyfin Server"}`, "too old"},
+		{"other json", http.StatusOK, `{"Id":"abc","ProductName":"Emby Server"}`, "not a Jellyfin server"},
+		{"web page", http.StatusOK, `<html></html>`, "not a Jellyfin server"},
+		{"not found", http.StatusNotFound, `not found`, "not a Jellyfin server"},
+		{"proxy login", http.StatusUnauthorized, `unauthorized`, "requires authentication (401 Unauthorized)"},
+		{"proxy denied", http.StatusForbidden, `forbidden`, "requires authentication (403 Forbidden)"},
+		{"proxy authentication", http.StatusProxyAuthRequired, ``, "requires authentication (407 Proxy Authentication Required)"},
+		{"bad gateway", http.StatusBadGateway, `bad gateway`, "server error (502 Bad Gateway)"},
+		{"unavailable", http.StatusServiceUnavailable, ``, "server error (503 Service Unavailable)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			_, err := Probe(server.URL, "test", "test", "test", HTTPOptions{})
+			switch {
+			case tt.wantErr == "" && err != nil:
+				t.Errorf("Probe() failed: %v", err)
+			case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+				t.Errorf("Probe() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -2,15 +2,152 @@ package jellyfin
 
 import (
 	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
 
 	"github.com/sj14/jellyfin-go/api"
 )
 
+// Sort is the order in which the server returns items
+type Sort struct {
+	By    api.ItemSortBy
+	Order api.SortOrder
+}
+
+// SortMode is an order that lists of items can be sorted in
+type SortMode struct {
+	Name string
+	Sort Sort // Order is the default direction for the mode
+}
+
+var SortModes = []SortMode{
+	{"Name", Sort{api.ITEMSORTBY_SORT_NAME, api.SORTORDER_ASCENDING}},
+	{"Premiere date", Sort{api.ITEMSORTBY_PREMIERE_DATE, api.SORTORDER_DESCENDING}},
+	{"Community rating", Sort{api.ITEMSORTBY_COMMUNITY_RATING, api.SORTORDER_DESCENDING}},
+	{"Runtime", Sort{api.ITEMSORTBY_RUNTIME, api.SORTORDER_ASCENDING}},
+	{"Date added", Sort{api.ITEMSORTBY_DATE_CREATED, api.SORTORDER_DESCENDING}},
+	{"Last played", Sort{api.ITEMSORTBY_DATE_PLAYED, api.SORTORDER_DESCENDING}},
+	{"Random", Sort{api.ITEMSORTBY_RANDOM, api.SORTORDER_ASCENDING}},
+}
+
+// ParseSort parses the values of Sort.By and Sort.Order as they are stored in the config file
+func ParseSort(by, order string) (Sort, bool) {
+	sortBy, err := api.NewItemSortByFromValue(by)
+	if err != nil {
+		return Sort{}, false
+	}
+	sortOrder, err := api.NewSortOrderFromValue(order)
+	if err != nil {
+		return Sort{}, false
+	}
+	return Sort{*sortBy, *sortOrder}, true
+}
+
+// Reversed returns s sorted in the opposite direction
+func (s Sort) Reversed() Sort {
+	if s.Order == api.SORTORDER_DESCENDING {
+		return Sort{s.By, api.SORTORDER_ASCENDING}
+	}
+	return Sort{s.By, api.SORTORDER_DESCENDING}
+}
+
+func (s Sort) Descending() bool {
+	return s.Order == api.SORTORDER_DESCENDING
+}
+
+// Filter narrows down lists of items on the server. Zero values don't filter anything.
+type Filter struct {
+	Types              []api.BaseItemKind // replaces the default item types of the request
+	Genres             []string
+	MinYear            int
+	MaxYear            int
+	OfficialRatings    []string
+	MinCommunityRating float64
+	Played             *bool
+}
+
+// IsZero reports whether f doesn't filter anything
+func (f Filter) IsZero() bool {
+	return len(f.Types) == 0 && len(f.Genres) == 0 && f.MinYear == 0 && f.MaxYear == 0 &&
+		len(f.OfficialRatings) == 0 && f.MinCommunityRating == 0 && f.Played == nil
+}
+
+func (f Filter) apply(req api.ApiGetItemsRequest) api.ApiGetItemsRequest {
+	if len(f.Types) > 0 {
+		req = req.IncludeItemTypes(f.Types)
+	}
+	if len(f.Genres) > 0 {
+		req = req.Genres(f.Genres)
+	}
+	if f.MinYear > 0 {
+		req = req.MinPremiereDate(time.Date(f.MinYear, time.January, 1, 0, 0, 0, 0, time.UTC))
+	}
+	if f.MaxYear > 0 {
+		req = req.MaxPremiereDate(time.Date(f.MaxYear+1, time.January, 1, 0, 0, 0, 0, time.UTC).Add(-time.Second))
+	}
+	if len(f.OfficialRatings) > 0 {
+		req = req.OfficialRatings(f.OfficialRatings)
+	}
+	if f.MinCommunityRating > 0 {
+		req = req.MinCommunityRating(f.MinCommunityRating)
+	}
+	if f.Played != nil {
+		req = req.IsPlayed(*f.Played)
+	}
+	return req
+}
+
+// Query holds the paging, sorting and filtering parameters of requests for potentially long lists of items. Lists
+// sorted randomly aren't paged since the server shuffles every page on its own, which would repeat and skip items.
+type Query struct {
+	StartIndex int
+	Limit      int
+	Sort       Sort // default order of the request if By is empty
+	Filter     Filter
+}
+
+func (q Query) apply(req api.ApiGetItemsRequest) api.ApiGetItemsRequest {
+	req = req.EnableTotalRecordCount(true)
+	if q.Sort.By != api.ITEMSORTBY_RANDOM {
+		req = req.StartIndex(int32(q.StartIndex)).Limit(int32(q.Limit))
+	}
+	if q.Sort.By != "" {
+		// sort by name second so that items with equal values keep a stable order across pages
+		req = req.SortBy([]api.ItemSortBy{q.Sort.By, api.ITEMSORTBY_SORT_NAME}).
+			SortOrder([]api.SortOrder{q.Sort.Order, api.SORTORDER_ASCENDING})
+	}
+	return q.Filter.apply(req)
+}
+
+// FilterOptions are the values that items inside of a parent can be filtered by
+type FilterOptions struct {
+	Genres          []string
+	OfficialRatings []string
+}
+
+// GetFilterOptions returns the genres and official ratings of the items inside of parent, or of all items if parentID is empty
+func (c *Client) GetFilterOptions(parentID string) (FilterOptions, error) {
+	req := c.api.FilterAPI.GetQueryFiltersLegacy(context.Background()).
+		UserId(c.UserID).
+		IncludeItemTypes([]api.BaseItemKind{api.BASEITEMKIND_MOVIE, api.BASEITEMKIND_SERIES, api.BASEITEMKIND_EPISODE, api.BASEITEMKIND_VIDEO})
+	if parentID != "" {
+		req = req.ParentId(parentID)
+	}
+	res, err := call(c, req.Execute)
+	if err != nil {
+		return FilterOptions{}, err
+	}
+	return FilterOptions{Genres: res.Genres, OfficialRatings: res.OfficialRatings}, nil
+}
+
 func (c *Client) GetResume() ([]Item, error) {
-	res, _, err := c.api.ItemsAPI.GetResumeItems(context.Background()).
+	res, err := call(c, c.api.ItemsAPI.GetResumeItems(context.Background()).
 		UserId(c.UserID).
-		Fields([]api.ItemFields{api.ITEMFIELDS_MEDIA_STREAMS}).
-		Execute()
+		Fields([]api.ItemFields{api.ITEMFIELDS_MEDIA_STREAMS, api.ITEMFIELDS_MEDIA_SOURCE_COUNT}).
+		Execute)
 	if err != nil {
 		return nil, err
 	}
@@ -18,83 +155,207 @@ func (c *Client) GetResume() ([]Item, error) {
 }
 
 func (c *Client) GetNextUp() ([]Item, error) {
-	res, _, err := c.api.TvShowsAPI.GetNextUp(context.Background()).
-		Fields([]api.ItemFields{api.ITEMFIELDS_MEDIA_STREAMS}).
+	res, err := call(c, c.api.TvShowsAPI.GetNextUp(context.Background()).
+		Fields([]api.ItemFields{api.ITEMFIELDS_MEDIA_STREAMS, api.ITEMFIELDS_MEDIA_SOURCE_COUNT}).
 		EnableTotalRecordCount(false).
 		DisableFirstEpisode(false).
 		EnableResumable(false).
 		EnableRewatching(false).
-		Execute()
+		Execute)
 	if err != nil {
 		return nil, err
 	}
 	return res.Items, nil
 }
 
-func (c *Client) GetRecentlyAdded() ([]Item, error) {
-	res, _, err := c.api.ItemsAPI.GetItems(context.Background()).
+// GetRecentlyAdded returns a page of the most recently added movies and series and the total number of items
+func (c *Client) GetRecentlyAdded(q Query) ([]Item, int, error) {
+	if q.Sort.By == "" {
+		q.Sort = Sort{By: api.ITEMSORTBY_DATE_CREATED, Order: api.SORTORDER_DESCENDING}
+	}
+	res, err := call(c, q.apply(c.api.ItemsAPI.GetItems(context.Background()).
 		Recursive(true).
 		IncludeItemTypes([]api.BaseItemKind{api.BASEITEMKIND_MOVIE, api.BASEITEMKIND_SERIES}).
-		Fields([]api.ItemFields{api.ITEMFIELDS_MEDIA_STREAMS}).
-		Limit(100).
-		SortBy([]api.ItemSortBy{api.ITEMSORTBY_DATE_CREATED}).
-		SortOrder([]api.SortOrder{api.SORTORDER_DESCENDING}).
-		Execute()
+		Fields([]api.ItemFields{api.ITEMFIELDS_MEDIA_STREAMS, api.ITEMFIELDS_MEDIA_SOURCE_COUNT})).
+		Execute)
+	if err != nil {
+		return nil, 0, err
+	}
+	return res.Items, int(res.GetTotalRecordCount()), nil
+}
+
+// GetSeasons returns the seasons of the series that item is or belongs to
+func (c *Client) GetSeasons(item Item) ([]Item, error) {
+	seriesID := item.GetSeriesId()
+	if item.GetType() == api.BASEITEMKIND_SERIES {
+		seriesID = item.GetId()
+	}
+	res, err := call(c, c.api.TvShowsAPI.GetSeasons(context.Background(), seriesID).
+		Fields([]api.ItemFields{api.ITEMFIELDS_CHILD_COUNT}).
+		EnableUserData(true).
+		Execute)
 	if err != nil {
 		return nil, err
 	}
 	return res.Items, nil
 }
 
+// GetEpisodes returns the episodes of a season if item is a season, or all episodes of the series that item is or belongs to otherwise
 func (c *Client) GetEpisodes(item Item) ([]Item, error) {
 	seriesID := item.GetSeriesId()
 	if item.GetType() == api.BASEITEMKIND_SERIES {
 		seriesID = item.GetId()
 	}
-	res, _, err := c.api.TvShowsAPI.GetEpisodes(context.Background(), seriesID).
-		Fields([]api.ItemFields{api.ITEMFIELDS_MEDIA_STREAMS}).
-		Execute()
+	req := c.api.TvShowsAPI.GetEpisodes(context.Background(), seriesID).
+		Fields([]api.ItemFields{api.ITEMFIELDS_MEDIA_STREAMS, api.ITEMFIELDS_MEDIA_SOURCE_COUNT})
+	if item.GetType() == api.BASEITEMKIND_SEASON {
+		req = req.SeasonId(item.GetId())
+	}
+	res, err := call(c, req.Execute)
 	if err != nil {
 		return nil, err
 	}
 	return res.Items, nil
 }
 
-func (c *Client) Search(query string) ([]Item, error) {
-	res, _, err := c.api.ItemsAPI.GetItems(context.Background()).
+// GetFavorites returns a page of the user's favorite movies, series and episodes and the total number of favorites
+func (c *Client) GetFavorites(q Query) ([]Item, int, error) {
+	if q.Sort.By == "" {
+		q.Sort = Sort{By: api.ITEMSORTBY_SORT_NAME, Order: api.SORTORDER_ASCENDING}
+	}
+	res, err := call(c, q.apply(c.api.ItemsAPI.GetItems(context.Background()).
+		IsFavorite(true).
+		Recursive(true).
+		IncludeItemTypes([]api.BaseItemKind{api.BASEITEMKIND_MOVIE, api.BASEITEMKIND_SERIES, api.BASEITEMKIND_EPISODE}).
+		Fields([]api.ItemFields{api.ITEMFIELDS_MEDIA_STREAMS, api.ITEMFIELDS_MEDIA_SOURCE_COUNT})).
+		Execute)
+	if err != nil {
+		return nil, 0, err
+	}
+	return res.Items, int(res.GetTotalRecordCount()), nil
+}
+
+// GetItem returns item with all of its fields, including the overview, people, genres, studios and media streams
+func (c *Client) GetItem(item Item) (Item, error) {
+	return c.GetItemByID(item.GetId())
+}
+
+// GetMediaSources returns the versions of item
+func (c *Client) GetMediaSources(item Item) ([]MediaSource, error) {
+	if sources := item.GetMediaSources(); len(sources) > 0 {
+		return sources, nil
+	}
+	full, err := c.GetItemByID(item.GetId())
+	if err != nil {
+		return nil, err
+	}
+	return full.GetMediaSources(), nil
+}
+
+// GetItemByID returns the item with the given id
+func (c *Client) GetItemByID(id string) (Item, error) {
+	res, err := call(c, c.api.UserLibraryAPI.GetItem(context.Background(), id).
+		UserId(c.UserID).
+		Execute)
+	if err != nil {
+		return Item{}, err
+	}
+	return *res, nil
+}
+
+// GetViews returns the user's libraries that contain videos
+func (c *Client) GetViews() ([]Item, error) {
+	res, err := call(c, c.api.UserViewsAPI.GetUserViews(context.Background()).
+		UserId(c.UserID).
+		Execute)
+	if err != nil {
+		return nil, err
+	}
+	views := make([]Item, 0, len(res.Items))
+	for _, view := range res.Items {
+		switch view.GetCollectionType() {
+		case api.COLLECTIONTYPE_MUSIC, api.COLLECTIONTYPE_BOOKS, api.COLLECTIONTYPE_PHOTOS, api.COLLECTIONTYPE_LIVETV, api.COLLECTIONTYPE_PLAYLISTS:
+			continue
+		}
+		views = append(views, view)
+	}
+	return views, nil
+}
+
+// GetLibraryItems returns a page of the movies, series, videos and collections inside of parent and the total number of items
+func (c *Client) GetLibraryItems(parentID string, q Query) ([]Item, int, error) {
+	if q.Sort.By == "" {
+		q.Sort = Sort{By: api.ITEMSORTBY_SORT_NAME, Order: api.SORTORDER_ASCENDING}
+	}
+	res, err := call(c, q.apply(c.api.ItemsAPI.GetItems(context.Background()).
+		ParentId(parentID).
+		Recursive(true).
+		IncludeItemTypes([]api.BaseItemKind{api.BASEITEMKIND_MOVIE, api.BASEITEMKIND_SERIES, api.BASEITEMKIND_VIDEO, api.BASEITEMKIND_BOX_SET}).
+		Fields([]api.ItemFields{api.ITEMFIELDS_MEDIA_STREAMS, api.ITEMFIELDS_MEDIA_SOURCE_COUNT, api.ITEMFIELDS_CHILD_COUNT})).
+		Execute)
+	if err != nil {
+		return nil, 0, err
+	}
+	return res.Items, int(res.GetTotalRecordCount()), nil
+}
+
+// Search returns a page of the movies and series matching query and the total number of matches
+func (c *Client) Search(query string, q Query) ([]Item, int, error) {
+	res, err := call(c, q.apply(c.api.ItemsAPI.GetItems(context.Background()).
 		SearchTerm(query).
 		Recursive(true).
 		IncludeItemTypes([]api.BaseItemKind{api.BASEITEMKIND_MOVIE, api.BASEITEMKIND_SERIES}).
-		Fields([]api.ItemFields{api.ITEMFIELDS_MEDIA_STREAMS}).
-		Limit(100).
-		Execute()
+		Fields([]api.ItemFields{api.ITEMFIELDS_MEDIA_STREAMS, api.ITEMFIELDS_MEDIA_SOURCE_COUNT})).
+		Execute)
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
-	return res.Items, nil
+	return res.Items, int(res.GetTotalRecordCount()), nil
 }
 
-func (c *Client) ReportPlaybackStart(item Item, ticks int64) error {
-	_, err := c.api.PlaystateAPI.ReportPlaybackStart(context.Background()).PlaybackStartInfo(api.PlaybackStartInfo{
-		ItemId:        item.Id,
-		PositionTicks: *api.NewNullableInt64(&ticks),
-	}).Execute()
+func (c *Client) ReportPlaybackStart(item Item, stream Stream, ticks int64, state PlayerState) error {
+	playMethod := stream.PlayMethod
+	audio, subtitle := stream.streamIndices()
+	err := do(c, c.api.PlaystateAPI.ReportPlaybackStart(context.Background()).PlaybackStartInfo(api.PlaybackStartInfo{
+		ItemId:              item.Id,
+		MediaSourceId:       nullableString(stream.MediaSourceID),
+		PlaySessionId:       nullableString(stream.PlaySessionID),
+		PositionTicks:       *api.NewNullableInt64(&ticks),
+		PlayMethod:          &playMethod,
+		AudioStreamIndex:    audio,
+		SubtitleStreamIndex: subtitle,
+		IsPaused:            &state.Paused,
+		IsMuted:             &state.Muted,
+		VolumeLevel:         *api.NewNullableInt32(api.PtrInt32(int32(state.Volume))),
+	}).Execute)
 	return err
 }
 
-func (c *Client) ReportPlaybackStopped(item Item, ticks int64) error {
-	_, err := c.api.PlaystateAPI.ReportPlaybackStopped(context.Background()).PlaybackStopInfo(api.PlaybackStopInfo{
+func (c *Client) ReportPlaybackStopped(item Item, stream Stream, ticks int64) error {
+	err := do(c, c.api.PlaystateAPI.ReportPlaybackStopped(context.Background()).PlaybackStopInfo(api.PlaybackStopInfo{
 		ItemId:        item.Id,
+		MediaSourceId: nullableString(stream.MediaSourceID),
+		PlaySessionId: nullableString(stream.PlaySessionID),
 		PositionTicks: *api.NewNullableInt64(&ticks),
-	}).Execute()
+	}).Execute)
 	return err
 }
 
-func (c *Client) ReportPlaybackProgress(item Item, ticks int64) error {
-	_, err := c.api.PlaystateAPI.ReportPlaybackProgress(context.Background()).PlaybackProgressInfo(api.PlaybackProgressInfo{
-		ItemId:        item.Id,
-		PositionTicks: *api.NewNullableInt64(&ticks),
-	}).Execute()
+func (c *Client) ReportPlaybackProgress(item Item, stream Stream, ticks int64, state PlayerState) error {
+	playMethod := stream.PlayMethod
+	audio, subtitle := stream.streamIndices()
+	err := do(c, c.api.PlaystateAPI.ReportPlaybackProgress(context.Background()).PlaybackProgressInfo(api.PlaybackProgressInfo{
+		ItemId:              item.Id,
+		MediaSourceId:       nullableString(stream.MediaSourceID),
+		PlaySessionId:       nullableString(stream.PlaySessionID),
+		PositionTicks:       *api.NewNullableInt64(&ticks),
+		PlayMethod:          &playMethod,
+		AudioStreamIndex:    audio,
+		SubtitleStreamIndex: subtitle,
+		IsPaused:            &state.Paused,
+		IsMuted:             &state.Muted,
+		VolumeLevel:         *api.NewNullableInt32(api.PtrInt32(int32(state.Volume))),
+	}).Execute)
 	return err
 }
 
@@ -111,7 +372,7 @@ func (c *Client) GetMediaSegments(item Item, types []string) (map[int64]int64, e
 	for i, t := range types {
 		mediaSegmentTypes[i] = api.MediaSegmentType(t)
 	}
-	res, _, err := c.api.MediaSegmentsAPI.GetItemSegments(context.Background(), item.GetId()).IncludeSegmentTypes(mediaSegmentTypes).Execute()
+	res, err := call(c, c.api.MediaSegmentsAPI.GetItemSegments(context.Background(), item.GetId()).IncludeSegmentTypes(mediaSegmentTypes).Execute)
 	if err != nil {
 		return nil, err
 	}
@@ -123,11 +384,43 @@ func (c *Client) GetMediaSegments(item Item, types []string) (map[int64]int64, e
 }
 
 func (c *Client) MarkAsWatched(item Item) error {
-	_, _, err := c.api.PlaystateAPI.MarkPlayedItem(context.Background(), item.GetId()).Execute()
+	_, err := call(c, c.api.PlaystateAPI.MarkPlayedItem(context.Background(), item.GetId()).Execute)
 	return err
 }
 
 func (c *Client) MarkAsUnwatched(item Item) error {
-	_, _, err := c.api.PlaystateAPI.MarkUnplayedItem(context.Background(), item.GetId()).Execute()
+	_, err := call(c, c.api.PlaystateAPI.MarkUnplayedItem(context.Background(), item.GetId()).Execute)
+	return err
+}
+
+func (c *Client) MarkFavorite(item Item) error {
+	_, err := call(c, c.api.UserLibraryAPI.MarkFavoriteItem(context.Background(), item.GetId()).Execute)
+	return err
+}
+
+func (c *Client) UnmarkFavorite(item Item) error {
+	_, err := call(c, c.api.UserLibraryAPI.UnmarkFavoriteItem(context.Background(), item.GetId()).Execute)
 	return err
 }
+
+// GetImage downloads img scaled down to at most maxHeight pixels tall, encoded as jpeg
+func (c *Client) GetImage(img Image, maxHeight int) ([]byte, error) {
+	u := fmt.Sprintf("%s/Items/%s/Images/%s?tag=%s&maxHeight=%d&format=Jpg&quality=90",
+		c.Host, url.PathEscape(img.ItemID), url.PathEscape(img.Type), url.QueryEscape(img.Tag), maxHeight)
+	return call(c, func() ([]byte, *http.Response, error) {
+		req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, u, nil)
+		if err != nil {
+			return nil, nil, err
+		}
+		res, err := c.api.GetConfig().HTTPClient.Do(req)
+		if err != nil {
+			return nil, nil, err
+		}
+		defer res.Body.Close()
+		if res.StatusCode != http.StatusOK {
+			return nil, res, fmt.Errorf("failed to get image: %s", res.Status)
+		}
+		data, err := io.ReadAll(res.Body)
+		return data, res, err
+	})
+}
@@ -0,0 +1,108 @@
+package jellyfin
+
+import (
+	"context"
+	"slices"
+	"strings"
+
+	"github.com/sj14/jellyfin-go/api"
+)
+
+// NoTrack is the stream index of a track that isn't played, e.g. when subtitles are off
+const NoTrack = -1
+
+// TrackPreferences decide which audio and subtitle tracks are played
+type TrackPreferences struct {
+	// AudioLanguage and SubtitleLanguage are ISO 639-2 codes like "eng", none preferred if empty
+	AudioLanguage, SubtitleLanguage string
+	// SubtitleMode is when subtitles are shown: Default, Always, OnlyForced, None or Smart like on the server, in any
+	// case, Default if empty
+	SubtitleMode string
+}
+
+// GetTrackPreferences returns the preferences of the user set on the server, overridden by the ones set in overrides
+func (c *Client) GetTrackPreferences(overrides TrackPreferences) (TrackPreferences, error) {
+	user, err := call(c, c.api.UserAPI.GetCurrentUser(context.Background()).Execute)
+	if err != nil {
+		return overrides, err
+	}
+	config := user.GetConfiguration()
+	prefs := TrackPreferences{
+		AudioLanguage:    config.GetAudioLanguagePreference(),
+		SubtitleLanguage: config.GetSubtitleLanguagePreference(),
+		SubtitleMode:     string(config.GetSubtitleMode()),
+	}
+	if overrides.AudioLanguage != "" {
+		prefs.AudioLanguage = overrides.AudioLanguage
+	}
+	if overrides.SubtitleLanguage != "" {
+		prefs.SubtitleLanguage = overrides.SubtitleLanguage
+	}
+	if overrides.SubtitleMode != "" {
+		prefs.SubtitleMode = overrides.SubtitleMode
+	}
+	return prefs, nil
+}
+
+// selectTracks returns the indices of the audio and subtitle streams to play according to prefs, NoTrack if there are
+// none to play
+func selectTracks(streams []api.MediaStream, prefs TrackPreferences) (audio, subtitle int32) {
+	var audios, subtitles []api.MediaStream
+	for _, stream := range streams {
+		switch stream.GetType() {
+		case api.MEDIASTREAMTYPE_AUDIO:
+			audios = append(audios, stream)
+		case api.MEDIASTREAMTYPE_SUBTITLE:
+			subtitles = append(subtitles, stream)
+		}
+	}
+
+	audio, subtitle = NoTrack, NoTrack
+	audioLanguage := ""
+	if stream, ok := pickStream(audios, prefs.AudioLanguage); ok {
+		audio = stream.GetIndex()
+		audioLanguage = stream.GetLanguage()
+	}
+
+	forced := slices.DeleteFunc(slices.Clone(subtitles), func(s api.MediaStream) bool { return !s.GetIsForced() })
+	var candidates []api.MediaStream
+	switch strings.ToLower(prefs.SubtitleMode) {
+	case "none":
+	case "onlyforced":
+		candidates = forced
+	case "always":
+		candidates = subtitles
+	case "smart":
+		// subtitles in the preferred language when the audio is in another language, only forced ones otherwise
+		candidates = forced
+		if prefs.AudioLanguage != "" && !strings.EqualFold(audioLanguage, prefs.AudioLanguage) {
+			candidates = slices.DeleteFunc(slices.Clone(subtitles), func(s api.MediaStream) bool {
+				return !strings.EqualFold(s.GetLanguage(), prefs.SubtitleLanguage)
+			})
+		}
+	default:
+		candidates = slices.DeleteFunc(slices.Clone(subtitles), func(s api.MediaStream) bool {
+			return !s.GetIsDefault() && !s.GetIsForced()
+		})
+	}
+	if stream, ok := pickStream(candidates, prefs.SubtitleLanguage); ok {
+		subtitle = stream.GetIndex()
+	}
+	return audio, subtitle
+}
+
+// pickStream returns the first stream in language, falling back to the default stream and then to the first one
+func pickStream(streams []api.MediaStream, language string) (api.MediaStream, bool) {
+	if len(streams) == 0 {
+		return api.MediaStream{}, false
+	}
+	if language != "" {
+		if i := slices.IndexFunc(streams, func(s api.MediaStream) bool { return strings.EqualFold(s.GetLanguage(), language) }); i >= 0 {
+			return streams[i], true
+		}
+	}
+	if i := slices.IndexFunc(streams, func(s api.MediaStream) bool { return s.GetIsDefault() }); i >= 0 {
+		return streams[i], true
+	}
+	return streams[0], true
+}
@@ -0,0 +1,117 @@
+package jellyfin
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/sj14/jellyfin-go/api"
+)
+
+func testStream(index int32, kind api.MediaStreamType, language string, flags ...string) api.MediaStream {
+	stream := api.MediaStream{}
+	stream.SetIndex(index)
+	stream.SetType(kind)
+	if language != "" {
+		stream.SetLanguage(language)
+	}
+	for _, flag := range flags {
+		switch flag {
+		case "default":
+			stream.SetIsDefault(true)
+		case "forced":
+			stream.SetIsForced(true)
+		case "external":
+			stream.SetIsExternal(true)
+		}
+	}
+	return stream
+}
+
+// an anime with japanese and english audio, full and forced english subtitles and an external french one
+var testStreams = []api.MediaStream{
+	testStream(0, api.MEDIASTREAMTYPE_VIDEO, ""),
+	testStream(1, api.MEDIASTREAMTYPE_AUDIO, "jpn", "default"),
+	testStream(2, api.MEDIASTREAMTYPE_AUDIO, "eng"),
+	testStream(3, api.MEDIASTREAMTYPE_SUBTITLE, "eng"),
+	testStream(4, api.MEDIASTREAMTYPE_SUBTITLE, "eng", "forced"),
+	testStream(5, api.MEDIASTREAMTYPE_SUBTITLE, "fre", "external"),
+}
+
+func TestSelectTracks(t *testing.T) {
+	tests := []struct {
+		name            string
+		prefs           TrackPreferences
+		audio, subtitle int32
+	}{
+		// the default mode shows default and forced subtitles
+		{"defaults", TrackPreferences{}, 1, 4},
+		{"audio language", TrackPreferences{AudioLanguage: "eng"}, 2, 4},
+		{"audio language in any case", TrackPreferences{AudioLanguage: "ENG"}, 2, 4},
+		{"missing audio language", TrackPreferences{AudioLanguage: "ger"}, 1, 4},
+		{"none", TrackPreferences{SubtitleMode: "None", SubtitleLanguage: "eng"}, 1, NoTrack},
+		{"only forced", TrackPreferences{SubtitleMode: "OnlyForced"}, 1, 4},
+		{"always", TrackPreferences{SubtitleMode: "Always", SubtitleLanguage: "eng"}, 1, 3},
+		{"always external", TrackPreferences{SubtitleMode: "always", SubtitleLanguage: "fre"}, 1, 5},
+		{"smart with foreign audio", TrackPreferences{SubtitleMode: "Smart", AudioLanguage: "ger", SubtitleLanguage: "eng"}, 1, 3},
+		{"smart with preferred audio", TrackPreferences{SubtitleMode: "Smart", AudioLanguage: "eng", SubtitleLanguage: "eng"}, 2, 4},
+		{"smart without subtitle language", TrackPreferences{SubtitleMode: "Smart", AudioLanguage: "ger"}, 1, NoTrack},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			audio, subtitle := selectTracks(testStreams, tt.prefs)
+			if audio != tt.audio || subtitle != tt.subtitle {
+				t.Errorf("selectTracks() = %d, %d, want %d, %d", audio, subtitle, tt.audio, tt.subtitle)
+			}
+		})
+	}
+}
+
+func TestSelectTracksWithoutStreams(t *testing.T) {
+	audio, subtitle := selectTracks(nil, TrackPreferences{SubtitleMode: "Always"})
+	if audio != NoTrack || subtitle != NoTrack {
+		t.Errorf("selectTracks() = %d, %d, want no tracks", audio, subtitle)
+	}
+}
+
+func TestWithTracks(t *testing.T) {
+	source := MediaSource{}
+	source.SetMediaStreams(testStreams)
+	const transcodingURL = "/videos/1/master.m3u8?MediaSourceId=1&AudioStreamIndex=1&SubtitleStreamIndex=3&SubtitleMethod=Hls"
+	tests := []struct {
+		name                    string
+		audio, subtitle         int32
+		wantAudio, wantSubtitle string
+		wantSubtitleMethod      string
+	}{
+		{"embedded subtitles are burned in", 2, 4, "2", "4", "Encode"},
+		{"external subtitles are added by mpv", 2, 5, "2", "", ""},
+		{"no subtitles", 1, NoTrack, "1", "", ""},
+		{"unknown audio keeps the server's", NoTrack, NoTrack, "1", "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stream := Stream{Source: source, AudioStreamIndex: tt.audio, SubtitleStreamIndex: tt.subtitle}
+			got, err := withTracks(transcodingURL, stream)
+			if err != nil {
+				t.Fatal(err)
+			}
+			u, err := url.Parse(got)
+			if err != nil {
+				t.Fatal(err)
+			}
+			query := u.Query()
+			if query.Get("MediaSourceId") != "1" {
+				t.Errorf("other parameters must be kept, got %s", got)
+			}
+			if query.Get("AudioStreamIndex") != tt.wantAudio {
+				t.Errorf("AudioStreamIndex = %q, want %q", query.Get("AudioStreamIndex"), tt.wantAudio)
+			}
+			if query.Get("SubtitleStreamIndex") != tt.wantSubtitle {
+				t.Errorf("SubtitleStreamIndex = %q, want %q", query.Get("SubtitleStreamIndex"), tt.wantSubtitle)
+			}
+			if query.Get("SubtitleMethod") != tt.wantSubtitleMethod {
+				t.Errorf("SubtitleMethod = %q, want %q", query.Get("SubtitleMethod"), tt.wantSubtitleMethod)
+			}
+		})
+	}
+}
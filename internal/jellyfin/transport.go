@@ -0,0 +1,74 @@
+package jellyfin
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+)
+
+// HTTPOptions configures how requests reach the server, for servers behind a private CA or a reverse proxy
+type HTTPOptions struct {
+	// CAFile is a PEM bundle of certificate authorities trusted on top of the system ones
+	CAFile string
+	// CertFile and KeyFile are a PEM client certificate and its key, for servers that require mutual TLS
+	CertFile, KeyFile string
+	// InsecureSkipVerify disables verifying the certificate of the server
+	InsecureSkipVerify bool
+	// Headers are sent with every request, e.g. the credentials of an authenticating reverse proxy. They can't include
+	// Authorization, which carries the token of the user.
+	Headers map[string]string
+}
+
+// headerTransport sets extra headers on every request
+type headerTransport struct {
+	headers map[string]string
+	base    http.RoundTripper
+}
+
+func (t *headerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	for k, v := range t.headers {
+		req.Header.Set(k, v)
+	}
+	return t.base.RoundTrip(req)
+}
+
+// newTransport returns the transport requests are sent with
+func newTransport(opts HTTPOptions) (http.RoundTripper, error) {
+	for name := range opts.Headers {
+		if http.CanonicalHeaderKey(name) == "Authorization" {
+			return nil, errors.New("the Authorization header can't be set in headers, it carries the token of the user")
+		}
+	}
+	tlsConfig := &tls.Config{InsecureSkipVerify: opts.InsecureSkipVerify}
+	if opts.CAFile != "" {
+		data, err := os.ReadFile(opts.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA file: %w", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(data) {
+			return nil, fmt.Errorf("no certificates found in %s", opts.CAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+	if opts.CertFile != "" || opts.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(opts.CertFile, opts.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConfig
+	if len(opts.Headers) > 0 {
+		return &headerTransport{headers: opts.Headers, base: transport}, nil
+	}
+	return transport, nil
+}
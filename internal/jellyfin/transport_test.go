@@ -0,0 +1,40 @@
+package jellyfin
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestNewTransportHeaders(t *testing.T) {
+	var got http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header
+	}))
+	defer server.Close()
+
+	transport, err := newTransport(HTTPOptions{Headers: map[string]string{"CF-Access-Client-Id": "id"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := &http.Client{Transport: &authTransport{header: func() string { return "MediaBrowser Token=\"t\"" }, base: transport}}
+	res, err := client.Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	if got.Get("Cf-Access-Client-Id") != "id" {
+		t.Errorf("configured header not sent, got %v", got)
+	}
+	if got.Get("Authorization") != "MediaBrowser Token=\"t\"" {
+		t.Errorf("Authorization = %q, want the token of the user", got.Get("Authorization"))
+	}
+}
+
+func TestNewTransportRejectsAuthorization(t *testing.T) {
+	for _, name := range []string{"Authorization", "authorization"} {
+		if _, err := newTransport(HTTPOptions{Headers: map[string]string{name: "Basic abc"}}); err == nil {
+			t.Errorf("header %q was accepted", name)
+		}
+	}
+}
@@ -6,10 +6,14 @@ import (
 	"encoding/json"
 	"fmt"
 	"log/slog"
+	"maps"
 	"net"
 	"os"
 	"os/exec"
+	"slices"
 	"strconv"
+
+	"github.com/hacel/jfsh/internal/jellyfin"
 )
 
 type request struct {
@@ -25,6 +29,7 @@ type response struct {
 	Reason     string `json:"reason,omitempty"`
 	Data       any    `json:"data"`
 	PlaylistID int    `json:"playlist_entry_id,omitempty"`
+	HookID     int    `json:"hook_id,omitempty"`
 }
 
 type mpv struct {
@@ -96,6 +101,55 @@ func (c *mpv) playFile(url, title string, start float64) error {
 	return c.send(cmd)
 }
 
-func (c *mpv) addSubtitle(url, title, lang string) error {
-	return c.send([]any{"sub-add", url, "auto", title, lang})
+// addSubtitle adds the subtitle track at url, selecting it if selected is set
+func (c *mpv) addSubtitle(url, title, lang string, selected bool) error {
+	flag := "auto"
+	if selected {
+		flag = "select"
+	}
+	return c.send([]any{"sub-add", url, flag, title, lang})
+}
+
+// showText shows text on screen for a few seconds
+func (c *mpv) showText(text string) error {
+	return c.send([]any{"show-text", text, 5000})
+}
+
+func (c *mpv) setProperty(name string, value any) error {
+	return c.send([]any{"set_property", name, value})
+}
+
+// addHook makes mpv wait at the hook called name until ackHook is sent back for it
+func (c *mpv) addHook(name string) error {
+	return c.send([]any{"hook-add", name, 1, 0})
+}
+
+func (c *mpv) ackHook(id int) error {
+	return c.send([]any{"hook-ack", id})
+}
+
+// httpArgs returns the options that let mpv reach the streams the same way the client reaches the server
+func httpArgs(opts jellyfin.HTTPOptions) []string {
+	var args []string
+	if opts.CAFile != "" {
+		args = append(args, "--tls-ca-file="+opts.CAFile)
+	}
+	if opts.CertFile != "" {
+		args = append(args, "--tls-cert-file="+opts.CertFile)
+	}
+	if opts.KeyFile != "" {
+		args = append(args, "--tls-key-file="+opts.KeyFile)
+	}
+	switch {
+	case opts.InsecureSkipVerify:
+		args = append(args, "--tls-verify=no")
+	case opts.CAFile != "":
+		// a CA file is only used when verifying
+		args = append(args, "--tls-verify=yes")
+	}
+	// appended one at a time since values may contain commas, the list separator
+	for _, name := range slices.Sorted(maps.Keys(opts.Headers)) {
+		args = append(args, "--http-header-fields-append="+name+": "+opts.Headers[name])
+	}
+	return args
 }
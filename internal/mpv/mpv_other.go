@@ -12,9 +12,10 @@ import (
 	"time"
 )
 
-func createMpv() (*mpv, error) {
+// createMpv starts mpv with args and connects to its ipc socket
+func createMpv(args ...string) (*mpv, error) {
 	socket := filepath.Join(os.TempDir(), fmt.Sprintf("jfsh-mpv-socket-%d", time.Now().UnixNano()))
-	cmd := exec.Command("mpv", "--idle", "--input-ipc-server="+socket)
+	cmd := exec.Command("mpv", append([]string{"--idle", "--input-ipc-server=" + socket}, args...)...)
 	if err := cmd.Start(); err != nil {
 		return nil, fmt.Errorf("failed to create mpv: %w", err)
 	}
@@ -14,9 +14,10 @@ import (
 	"github.com/Microsoft/go-winio"
 )
 
-func createMpv() (*mpv, error) {
+// createMpv starts mpv with args and connects to its ipc socket
+func createMpv(args ...string) (*mpv, error) {
 	pipe := `\\.\pipe\jfsh-mpv-` + strconv.FormatInt(time.Now().UnixNano(), 10)
-	cmd := exec.Command("mpv", "--idle", "--input-ipc-server="+pipe)
+	cmd := exec.Command("mpv", append([]string{"--idle", "--input-ipc-server=" + pipe}, args...)...)
 	if err := cmd.Start(); err != nil {
 		return nil, fmt.Errorf("failed to create mpv: %w", err)
 	}
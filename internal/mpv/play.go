@@ -2,11 +2,14 @@ package mpv
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
+	"strconv"
 	"time"
 
 	"github.com/hacel/jfsh/internal/jellyfin"
+	"github.com/sj14/jellyfin-go/api"
 	"github.com/spf13/viper"
 )
 
@@ -28,36 +31,154 @@ func isInsideSkippableSegment(segments map[float64]float64, pos float64) float64
 	return 0
 }
 
-func Play(client *jellyfin.Client, items []jellyfin.Item, index int) error {
-	mpv, err := createMpv()
+// selectTracks makes mpv play the audio and embedded subtitle tracks picked for stream, leaving the choice to mpv if
+// they are unknown. External subtitles are selected as they are added.
+func selectTracks(mpv *mpv, stream jellyfin.Stream) error {
+	aid, sid := "auto", "auto"
+	if len(stream.Source.GetMediaStreams()) > 0 {
+		// transcodes only carry the picked audio track and have embedded subtitles burned in
+		if stream.PlayMethod != api.PLAYMETHOD_TRANSCODE {
+			if id := trackID(stream, stream.AudioStreamIndex); id > 0 {
+				aid = strconv.Itoa(id)
+			}
+		}
+		sid = "no"
+		if id := trackID(stream, stream.SubtitleStreamIndex); id > 0 && stream.PlayMethod != api.PLAYMETHOD_TRANSCODE {
+			sid = strconv.Itoa(id)
+		}
+	}
+	if err := mpv.setProperty("aid", aid); err != nil {
+		return err
+	}
+	return mpv.setProperty("sid", sid)
+}
+
+// trackID returns the mpv id of the embedded stream with index, which counts the embedded streams of the same type
+// from 1, or 0 if it isn't an embedded stream
+func trackID(stream jellyfin.Stream, index int32) int {
+	target, ok := stream.GetStream(index)
+	if !ok || target.GetIsExternal() {
+		return 0
+	}
+	id := 0
+	for _, s := range stream.Source.GetMediaStreams() {
+		if s.GetType() == target.GetType() && !s.GetIsExternal() && s.GetIndex() <= index {
+			id++
+		}
+	}
+	return id
+}
+
+// streamIndex returns the index of the stream mpv plays as the track id of streamType, the inverse of trackID, given the
+// stream picked when the file started and the external subtitles added to it in order. It returns NoTrack if there is
+// none or it is unknown.
+func streamIndex(picked jellyfin.Stream, subtitles []jellyfin.ExternalSubtitleStream, streamType api.MediaStreamType, id int) int32 {
+	if picked.PlayMethod == api.PLAYMETHOD_TRANSCODE {
+		// transcodes carry the picked audio track alone, and burned in subtitles aren't tracks of their own
+		if streamType == api.MEDIASTREAMTYPE_AUDIO {
+			return picked.AudioStreamIndex
+		}
+		if subtitle, ok := picked.GetStream(picked.SubtitleStreamIndex); ok && !subtitle.GetIsExternal() {
+			return picked.SubtitleStreamIndex
+		}
+	}
+	if id <= 0 {
+		return jellyfin.NoTrack
+	}
+	if picked.PlayMethod != api.PLAYMETHOD_TRANSCODE {
+		for _, s := range picked.Source.GetMediaStreams() {
+			if s.GetType() != streamType || s.GetIsExternal() {
+				continue
+			}
+			if id--; id == 0 {
+				return s.GetIndex()
+			}
+		}
+	}
+	// external subtitles come after the embedded tracks
+	if streamType == api.MEDIASTREAMTYPE_SUBTITLE && id <= len(subtitles) {
+		return subtitles[id-1].Index
+	}
+	return jellyfin.NoTrack
+}
+
+// trackNumber returns the track id in the data of a changed aid or sid, 0 if no track is played
+func trackNumber(data any) int {
+	id, _ := data.(float64)
+	return int(id)
+}
+
+// getStream asks the server how to play the media source of item with id mediaSourceID, falling back to playing the
+// file as is unless opts limit the playback. The failure is returned as warning when it falls back.
+func getStream(client *jellyfin.Client, item jellyfin.Item, mediaSourceID string, opts jellyfin.PlaybackOptions) (stream jellyfin.Stream, warning error, err error) {
+	stream, err = client.GetStream(item, mediaSourceID, opts)
+	if err != nil {
+		// the file as is ignores the limits, which may be there because it can't be played
+		if opts.Limited() {
+			return jellyfin.Stream{}, nil, fmt.Errorf("failed to get stream of %s: %w", item.GetName(), err)
+		}
+		slog.Error("failed to get stream, playing the file as is", "item", item.GetName(), "err", err)
+		warning = fmt.Errorf("played %s as is, failed to get stream: %w", item.GetName(), err)
+		return jellyfin.StaticStream(client.Host, item), warning, nil
+	}
+	slog.Info("got stream", "item", item.GetName(), "method", stream.PlayMethod, "url", stream.URL)
+	return stream, nil, nil
+}
+
+// Play plays items in mpv starting at index, which is played from the media source with id mediaSourceID, or its
+// default one if empty. Playback stops with an error when the server can't tell how to play an item within the limits
+// of opts. Items that could only be played as is are reported back in the error once playback stops.
+func Play(client *jellyfin.Client, items []jellyfin.Item, index int, mediaSourceID string, opts jellyfin.PlaybackOptions) error {
+	mpv, err := createMpv(httpArgs(client.HTTP)...)
 	if err != nil {
 		return fmt.Errorf("failed to create mpv client: %w", err)
 	}
 	defer mpv.close()
 
+	if opts.Tracks, err = client.GetTrackPreferences(opts.Tracks); err != nil {
+		slog.Error("failed to get track preferences", "err", err)
+	}
+
 	// makes mpv report position in file
 	if err := mpv.observeProperty("time-pos"); err != nil {
 		// NOTE: is this a fatal error?
 		return fmt.Errorf("failed to observe time-pos: %w", err)
 	}
 
+	// makes mpv report the changes the server is told about right away
+	for _, name := range []string{"pause", "mute", "volume", "aid", "sid", "speed"} {
+		if err := mpv.observeProperty(name); err != nil {
+			slog.Error("failed to observe property", "name", name, "err", err)
+		}
+	}
+
+	// lets the stream of a file be picked once it starts, see the hook event below
+	if err := mpv.addHook("on_load"); err != nil {
+		slog.Error("failed to add on_load hook", "err", err)
+	}
+
 	// keeps track of the playlist index of items as they get loaded into mpv
 	playlistIDs := make([]int, 0, len(items))
 
 	// load file specified by index
-	url := jellyfin.GetStreamingURL(client.Host, items[index])
+	streams := make([]jellyfin.Stream, len(items))
+	// failures of the items played as is anyway, shown once they start and after playback
+	warnings := make([]error, len(items))
+	if streams[index], warnings[index], err = getStream(client, items[index], mediaSourceID, opts); err != nil {
+		return err
+	}
 	start := ticksToSeconds(jellyfin.GetResumePosition(items[index]))
 	title := jellyfin.GetMediaTitle(items[index])
-	if err := mpv.playFile(url, title, start); err != nil {
+	if err := mpv.playFile(streams[index].URL, title, start); err != nil {
 		return fmt.Errorf("failed to play file: %w", err)
 	}
 	playlistIDs = append(playlistIDs, index)
 
-	// append to playlist the files after the index
+	// append to playlist the files after the index. They are queued as stored on the server, which is only asked how
+	// to play them once they start so that queueing a whole series doesn't open a play session for each episode.
 	for i := index + 1; i < len(items); i++ {
-		url := jellyfin.GetStreamingURL(client.Host, items[i])
 		title := jellyfin.GetMediaTitle(items[i])
-		if err := mpv.appendFile(url, title); err != nil {
+		if err := mpv.appendFile(jellyfin.StaticStream(client.Host, items[i]).URL, title); err != nil {
 			slog.Error("failed to append file to playlist", "err", err)
 		}
 		playlistIDs = append(playlistIDs, i)
@@ -65,9 +186,8 @@ func Play(client *jellyfin.Client, items []jellyfin.Item, index int) error {
 
 	// prepend to playlist the files before the index
 	for i := index - 1; i >= 0; i-- {
-		url := jellyfin.GetStreamingURL(client.Host, items[i])
 		title := jellyfin.GetMediaTitle(items[i])
-		if err := mpv.prependFile(url, title); err != nil {
+		if err := mpv.prependFile(jellyfin.StaticStream(client.Host, items[i]).URL, title); err != nil {
 			slog.Error("failed to prepend file to playlist", "err", err)
 		}
 		playlistIDs = append(playlistIDs, i)
@@ -76,6 +196,12 @@ func Play(client *jellyfin.Client, items []jellyfin.Item, index int) error {
 	pos := float64(0)
 	lastProgressUpdate := time.Now()
 	item := items[index]
+	stream := streams[index]
+	// the stream as picked when the current file started, stream follows the tracks changed in mpv
+	picked := stream
+	var subtitles []jellyfin.ExternalSubtitleStream
+	state := jellyfin.PlayerState{Volume: 100}
+	playing := false
 	skippableSegmentTypes := viper.GetStringSlice("skip_segments")
 	skippableSegments := make(map[float64]float64)
 	for mpv.scanner.Scan() {
@@ -110,14 +236,42 @@ func Play(client *jellyfin.Client, items []jellyfin.Item, index int) error {
 
 				// debounced progress reporting
 				if time.Since(lastProgressUpdate) > 3*time.Second {
-					if err := client.ReportPlaybackProgress(item, secondsToTicks(pos)); err != nil {
+					if err := client.ReportPlaybackProgress(item, stream, secondsToTicks(pos), state); err != nil {
 						slog.Error("failed to report playback progress", "err", err)
 						continue
 					}
 					slog.Info("reported progress", "item", item.GetName(), "pos", pos)
 					lastProgressUpdate = time.Now()
 				}
+				continue
+
+			case "pause":
+				state.Paused, _ = response.Data.(bool)
+			case "mute":
+				state.Muted, _ = response.Data.(bool)
+			case "volume":
+				volume, _ := response.Data.(float64)
+				state.Volume = min(int(volume), 100)
+			case "aid":
+				stream.AudioStreamIndex = streamIndex(picked, subtitles, api.MEDIASTREAMTYPE_AUDIO, trackNumber(response.Data))
+			case "sid":
+				stream.SubtitleStreamIndex = streamIndex(picked, subtitles, api.MEDIASTREAMTYPE_SUBTITLE, trackNumber(response.Data))
+			case "speed":
+				// the server can't be told the speed, but the position it extrapolates is off until the next report
+			default:
+				continue
+			}
+
+			// changes are reported right away instead of with the next position
+			if !playing {
+				continue
+			}
+			if err := client.ReportPlaybackProgress(item, stream, secondsToTicks(pos), state); err != nil {
+				slog.Error("failed to report playback progress", "err", err)
+				continue
 			}
+			slog.Info("reported change", "item", item.GetName(), "property", response.Name, "data", response.Data)
+			lastProgressUpdate = time.Now()
 
 		case "start-file":
 			// figure out what item is being played
@@ -127,14 +281,29 @@ func Play(client *jellyfin.Client, items []jellyfin.Item, index int) error {
 				// user probably loaded something manually
 				return fmt.Errorf("start-file event for unknown playlist id: %d", response.PlaylistID)
 			}
-			item = items[playlistIDs[response.PlaylistID-1]]
+			current := playlistIDs[response.PlaylistID-1]
+			if streams[current].URL == "" {
+				if streams[current], warnings[current], err = getStream(client, items[current], "", opts); err != nil {
+					return err
+				}
+			}
+			if warnings[current] != nil {
+				if err := mpv.showText(warnings[current].Error()); err != nil {
+					slog.Error("failed to show warning", "err", err)
+				}
+			}
+			item = items[current]
+			stream = streams[current]
+			picked = stream
+			subtitles = jellyfin.GetExternalSubtitleStreams(item, stream.Source)
+			playing = true
 			slog.Info("received", "event", response.Event, "playlist_id", response.PlaylistID, "index", playlistIDs[response.PlaylistID-1], "item", item.GetName())
 
 			// report playback start
-			if err := client.ReportPlaybackStart(item, secondsToTicks(pos)); err != nil {
+			if err := client.ReportPlaybackStart(item, stream, secondsToTicks(pos), state); err != nil {
 				slog.Error("failed to report playback progress", "err", err)
 			} else {
-				slog.Info("reported playback start", "item", item.GetName(), "pos", pos)
+				slog.Info("reported playback start", "item", item.GetName(), "pos", pos, "method", stream.PlayMethod)
 			}
 
 			// get skippable segments
@@ -148,24 +317,40 @@ func Play(client *jellyfin.Client, items []jellyfin.Item, index int) error {
 				slog.Info("got skippable segments", "segments", segments)
 			}
 
+			if err := selectTracks(mpv, stream); err != nil {
+				slog.Error("failed to select tracks", "err", err)
+			} else {
+				slog.Info("selected tracks", "audio", stream.AudioStreamIndex, "subtitle", stream.SubtitleStreamIndex)
+			}
+
 			// load external subtitles
-			subtitles := jellyfin.GetExternalSubtitleStreams(item)
 			for _, subtitle := range subtitles {
 				subtitleURL := client.Host + subtitle.Path
-				if err := mpv.addSubtitle(subtitleURL, subtitle.Title, subtitle.Language); err != nil {
+				selected := subtitle.Index == stream.SubtitleStreamIndex
+				if err := mpv.addSubtitle(subtitleURL, subtitle.Title, subtitle.Language, selected); err != nil {
 					slog.Error("failed to add subtitle", "err", err, "title", subtitle.Title, "language", subtitle.Language)
 				} else {
 					slog.Info("added subtitle", "title", subtitle.Title, "language", subtitle.Language)
 				}
 			}
 
+		case "hook":
+			// mpv waits for the hook before opening the file, which is swapped for the stream picked on start-file
+			if err := mpv.setProperty("stream-open-filename", stream.URL); err != nil {
+				slog.Error("failed to set stream", "err", err)
+			}
+			if err := mpv.ackHook(response.HookID); err != nil {
+				slog.Error("failed to acknowledge hook", "err", err)
+			}
+
 		case "seek":
 			slog.Info("received", "event", response.Event, "item", item.GetName())
 			lastProgressUpdate = time.Time{}
 
 		case "end-file", "shutdown":
 			slog.Info("received", "event", response.Event, "item", item.GetName())
-			if err := client.ReportPlaybackStopped(item, secondsToTicks(pos)); err != nil {
+			playing = false
+			if err := client.ReportPlaybackStopped(item, stream, secondsToTicks(pos)); err != nil {
 				slog.Error("failed to report playback stopped", "err", err)
 			} else {
 				slog.Info("reported playback stopped", "item", item.GetName(), "pos", pos)
@@ -175,5 +360,5 @@ func Play(client *jellyfin.Client, items []jellyfin.Item, index int) error {
 	if err := mpv.scanner.Err(); err != nil {
 		return fmt.Errorf("failed to read mpv output: %w", err)
 	}
-	return nil
+	return errors.Join(warnings...)
 }
@@ -0,0 +1,114 @@
+package mpv
+
+import (
+	"testing"
+
+	"github.com/hacel/jfsh/internal/jellyfin"
+	"github.com/sj14/jellyfin-go/api"
+)
+
+func testStream(index int32, kind api.MediaStreamType, external bool) api.MediaStream {
+	stream := api.MediaStream{}
+	stream.SetIndex(index)
+	stream.SetType(kind)
+	stream.SetIsExternal(external)
+	return stream
+}
+
+// testSource has two audio tracks, two embedded subtitles and two external ones, one of them listed first like newer
+// servers do
+func testSource() jellyfin.MediaSource {
+	source := jellyfin.MediaSource{}
+	source.SetId("source")
+	source.SetMediaStreams([]api.MediaStream{
+		testStream(0, api.MEDIASTREAMTYPE_SUBTITLE, true),
+		testStream(1, api.MEDIASTREAMTYPE_VIDEO, false),
+		testStream(2, api.MEDIASTREAMTYPE_AUDIO, false),
+		testStream(3, api.MEDIASTREAMTYPE_AUDIO, false),
+		testStream(4, api.MEDIASTREAMTYPE_SUBTITLE, false),
+		testStream(5, api.MEDIASTREAMTYPE_SUBTITLE, false),
+		testStream(6, api.MEDIASTREAMTYPE_SUBTITLE, true),
+	})
+	return source
+}
+
+func testStreamOf(method api.PlayMethod, audio, subtitle int32) jellyfin.Stream {
+	return jellyfin.Stream{Source: testSource(), PlayMethod: method, AudioStreamIndex: audio, SubtitleStreamIndex: subtitle}
+}
+
+func TestTrackID(t *testing.T) {
+	stream := testStreamOf(api.PLAYMETHOD_DIRECT_PLAY, 2, 4)
+	tests := []struct {
+		index int32
+		want  int
+	}{
+		{1, 1},
+		{2, 1},
+		{3, 2},
+		{4, 1},
+		{5, 2},
+		// external subtitles are added by jfsh, they aren't tracks of the file
+		{0, 0},
+		{6, 0},
+		{jellyfin.NoTrack, 0},
+	}
+	for _, tt := range tests {
+		if got := trackID(stream, tt.index); got != tt.want {
+			t.Errorf("trackID(%d) = %d, want %d", tt.index, got, tt.want)
+		}
+	}
+}
+
+func TestStreamIndex(t *testing.T) {
+	item := jellyfin.Item{}
+	item.SetId("item")
+	// added to mpv in this order, after the embedded tracks
+	subtitles := jellyfin.GetExternalSubtitleStreams(item, testSource())
+	if len(subtitles) != 2 || subtitles[0].Index != 0 || subtitles[1].Index != 6 {
+		t.Fatalf("unexpected external subtitles %+v", subtitles)
+	}
+
+	direct := testStreamOf(api.PLAYMETHOD_DIRECT_PLAY, 2, 4)
+	burnedIn := testStreamOf(api.PLAYMETHOD_TRANSCODE, 3, 5)
+	transcodeWithExternal := testStreamOf(api.PLAYMETHOD_TRANSCODE, 3, 6)
+	tests := []struct {
+		name       string
+		picked     jellyfin.Stream
+		streamType api.MediaStreamType
+		id         int
+		want       int32
+	}{
+		{"embedded audio", direct, api.MEDIASTREAMTYPE_AUDIO, 2, 3},
+		{"no audio", direct, api.MEDIASTREAMTYPE_AUDIO, 0, jellyfin.NoTrack},
+		{"embedded subtitle", direct, api.MEDIASTREAMTYPE_SUBTITLE, 2, 5},
+		{"first external subtitle", direct, api.MEDIASTREAMTYPE_SUBTITLE, 3, 0},
+		{"second external subtitle", direct, api.MEDIASTREAMTYPE_SUBTITLE, 4, 6},
+		{"unknown subtitle", direct, api.MEDIASTREAMTYPE_SUBTITLE, 5, jellyfin.NoTrack},
+		{"no subtitle", direct, api.MEDIASTREAMTYPE_SUBTITLE, 0, jellyfin.NoTrack},
+		{"transcoded audio", burnedIn, api.MEDIASTREAMTYPE_AUDIO, 1, 3},
+		{"burned in subtitle", burnedIn, api.MEDIASTREAMTYPE_SUBTITLE, 0, 5},
+		{"external subtitle of a transcode", transcodeWithExternal, api.MEDIASTREAMTYPE_SUBTITLE, 2, 6},
+		{"no subtitle on a transcode", transcodeWithExternal, api.MEDIASTREAMTYPE_SUBTITLE, 0, jellyfin.NoTrack},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := streamIndex(tt.picked, subtitles, tt.streamType, tt.id); got != tt.want {
+				t.Errorf("streamIndex() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTrackIDRoundTrip(t *testing.T) {
+	stream := testStreamOf(api.PLAYMETHOD_DIRECT_PLAY, 3, 5)
+	for _, index := range []int32{2, 3} {
+		if got := streamIndex(stream, nil, api.MEDIASTREAMTYPE_AUDIO, trackID(stream, index)); got != index {
+			t.Errorf("audio %d came back as %d", index, got)
+		}
+	}
+	for _, index := range []int32{4, 5} {
+		if got := streamIndex(stream, nil, api.MEDIASTREAMTYPE_SUBTITLE, trackID(stream, index)); got != index {
+			t.Errorf("subtitle %d came back as %d", index, got)
+		}
+	}
+}
@@ -8,22 +8,28 @@ import (
 // KeyMap defines keybindings. It satisfies to the help.KeyMap interface, which is used to render the menu.
 type KeyMap struct {
 	// Keybindings used when browsing the list.
-	CursorUp      key.Binding
-	CursorDown    key.Binding
-	PageUp        key.Binding
-	PageDown      key.Binding
-	NextTab       key.Binding
-	PrevTab       key.Binding
-	GoToStart     key.Binding
-	GoToEnd       key.Binding
-	Search        key.Binding
-	ClearSearch   key.Binding
-	Filter        key.Binding
-	ClearFilter   key.Binding
-	Select        key.Binding
-	Back          key.Binding
-	ToggleWatched key.Binding
-	Refresh       key.Binding
+	CursorUp       key.Binding
+	CursorDown     key.Binding
+	PageUp         key.Binding
+	PageDown       key.Binding
+	NextTab        key.Binding
+	PrevTab        key.Binding
+	GoToStart      key.Binding
+	GoToEnd        key.Binding
+	Search         key.Binding
+	ClearSearch    key.Binding
+	Filter         key.Binding
+	ClearFilter    key.Binding
+	Select         key.Binding
+	ShowDetails    key.Binding
+	Back           key.Binding
+	ToggleWatched  key.Binding
+	ToggleFavorite key.Binding
+	Sort           key.Binding
+	EditFilters    key.Binding
+	Refresh        key.Binding
+	SwitchProfile  key.Binding
+	CycleQuality   key.Binding
 
 	// Keybindings used when searching.
 	CancelWhileSearching key.Binding
@@ -33,6 +39,25 @@ type KeyMap struct {
 	CancelWhileFiltering key.Binding
 	AcceptWhileFiltering key.Binding
 
+	// Keybindings used when picking a sort order.
+	CancelWhileSorting key.Binding
+	AcceptWhileSorting key.Binding
+
+	// Keybindings used when picking the version of an item to play.
+	CancelWhilePickingSource key.Binding
+	AcceptWhilePickingSource key.Binding
+
+	// Keybindings used in the detail view.
+	PlayFromStart key.Binding
+	CloseDetails  key.Binding
+
+	// Keybindings used in the filter panel.
+	CancelWhileEditingFilters key.Binding
+	AcceptWhileEditingFilters key.Binding
+	NextFilterInput           key.Binding
+	PrevFilterInput           key.Binding
+	ClearFilters              key.Binding
+
 	// Help toggle keybindings.
 	ShowFullHelp  key.Binding
 	CloseFullHelp key.Binding
@@ -99,6 +124,10 @@ func defaultKeyMap() KeyMap {
 			key.WithKeys("enter", "space"),
 			key.WithHelp("enter", "select"),
 		),
+		ShowDetails: key.NewBinding(
+			key.WithKeys("i"),
+			key.WithHelp("i", "details"),
+		),
 		Back: key.NewBinding(
 			key.WithKeys("esc", "backspace"),
 			key.WithHelp("esc", "back"),
@@ -108,10 +137,30 @@ func defaultKeyMap() KeyMap {
 			key.WithKeys("w"),
 			key.WithHelp("w", "toggle watched"),
 		),
+		ToggleFavorite: key.NewBinding(
+			key.WithKeys("*"),
+			key.WithHelp("*", "toggle favorite"),
+		),
+		Sort: key.NewBinding(
+			key.WithKeys("s"),
+			key.WithHelp("s", "sort"),
+		),
+		EditFilters: key.NewBinding(
+			key.WithKeys("F"),
+			key.WithHelp("F", "filters"),
+		),
 		Refresh: key.NewBinding(
 			key.WithKeys("r"),
 			key.WithHelp("r", "refresh"),
 		),
+		SwitchProfile: key.NewBinding(
+			key.WithKeys("P"),
+			key.WithHelp("P", "profiles"),
+		),
+		CycleQuality: key.NewBinding(
+			key.WithKeys("Q"),
+			key.WithHelp("Q", "quality"),
+		),
 
 		// Searching.
 		CancelWhileSearching: key.NewBinding(
@@ -133,6 +182,58 @@ func defaultKeyMap() KeyMap {
 			key.WithHelp("enter", "apply"),
 		),
 
+		// Sorting.
+		CancelWhileSorting: key.NewBinding(
+			key.WithKeys("esc"),
+			key.WithHelp("esc", "cancel"),
+		),
+		AcceptWhileSorting: key.NewBinding(
+			key.WithKeys("enter", "space"),
+			key.WithHelp("enter", "apply"),
+		),
+
+		// Picking a version.
+		CancelWhilePickingSource: key.NewBinding(
+			key.WithKeys("esc"),
+			key.WithHelp("esc", "cancel"),
+		),
+		AcceptWhilePickingSource: key.NewBinding(
+			key.WithKeys("enter", "space"),
+			key.WithHelp("enter", "play"),
+		),
+
+		// Detail view.
+		PlayFromStart: key.NewBinding(
+			key.WithKeys("p"),
+			key.WithHelp("p", "play from start"),
+		),
+		CloseDetails: key.NewBinding(
+			key.WithKeys("esc", "backspace", "i"),
+			key.WithHelp("esc", "back"),
+		),
+
+		// Filter panel.
+		CancelWhileEditingFilters: key.NewBinding(
+			key.WithKeys("esc"),
+			key.WithHelp("esc", "cancel"),
+		),
+		AcceptWhileEditingFilters: key.NewBinding(
+			key.WithKeys("enter"),
+			key.WithHelp("enter", "apply"),
+		),
+		NextFilterInput: key.NewBinding(
+			key.WithKeys("tab", "down"),
+			key.WithHelp("tab", "next field"),
+		),
+		PrevFilterInput: key.NewBinding(
+			key.WithKeys("shift+tab", "up"),
+			key.WithHelp("shift+tab", "prev field"),
+		),
+		ClearFilters: key.NewBinding(
+			key.WithKeys("ctrl+r"),
+			key.WithHelp("ctrl+r", "clear all"),
+		),
+
 		// Toggle help.
 		ShowFullHelp: key.NewBinding(
 			key.WithKeys("?"),
@@ -169,14 +270,20 @@ func (k KeyMap) FullHelp() [][]key.Binding {
 			k.PrevTab,
 			k.Refresh,
 			k.Select,
+			k.ShowDetails,
 			k.Search,
 			k.ClearSearch,
 			k.Filter,
 			k.ClearFilter,
+			k.Sort,
+			k.EditFilters,
 		},
 		[]key.Binding{
 			k.ToggleWatched,
+			k.ToggleFavorite,
 			k.Back,
+			k.SwitchProfile,
+			k.CycleQuality,
 			k.Quit,
 			k.CloseFullHelp,
 		})
@@ -186,7 +293,10 @@ func (k KeyMap) FullHelp() [][]key.Binding {
 func (k KeyMap) ShortHelp() []key.Binding {
 	return []key.Binding{
 		k.Back,
+		k.CloseDetails,
+		k.PlayFromStart,
 		k.ToggleWatched,
+		k.ToggleFavorite,
 
 		k.Search,
 		k.ClearSearch,
@@ -198,6 +308,18 @@ func (k KeyMap) ShortHelp() []key.Binding {
 		k.CancelWhileFiltering,
 		k.AcceptWhileFiltering,
 
+		k.CancelWhileSorting,
+		k.AcceptWhileSorting,
+
+		k.CancelWhilePickingSource,
+		k.AcceptWhilePickingSource,
+
+		k.CancelWhileEditingFilters,
+		k.AcceptWhileEditingFilters,
+		k.NextFilterInput,
+		k.PrevFilterInput,
+		k.ClearFilters,
+
 		k.ShowFullHelp,
 		k.Quit,
 	}
@@ -218,13 +340,153 @@ func (m *model) updateKeys() {
 		m.keyMap.Filter.SetEnabled(false)
 		m.keyMap.ClearFilter.SetEnabled(false)
 		m.keyMap.Select.SetEnabled(false)
+		m.keyMap.ShowDetails.SetEnabled(false)
 		m.keyMap.Back.SetEnabled(false)
 		m.keyMap.ToggleWatched.SetEnabled(false)
+		m.keyMap.ToggleFavorite.SetEnabled(false)
+		m.keyMap.Sort.SetEnabled(false)
+		m.keyMap.EditFilters.SetEnabled(false)
 		m.keyMap.Refresh.SetEnabled(false)
+		m.keyMap.SwitchProfile.SetEnabled(false)
+		m.keyMap.CycleQuality.SetEnabled(false)
 		m.keyMap.CancelWhileSearching.SetEnabled(false)
 		m.keyMap.AcceptWhileSearching.SetEnabled(false)
 		m.keyMap.CancelWhileFiltering.SetEnabled(true)
 		m.keyMap.AcceptWhileFiltering.SetEnabled(true)
+		m.keyMap.CancelWhileSorting.SetEnabled(false)
+		m.keyMap.AcceptWhileSorting.SetEnabled(false)
+		m.keyMap.CancelWhilePickingSource.SetEnabled(false)
+		m.keyMap.AcceptWhilePickingSource.SetEnabled(false)
+		m.keyMap.PlayFromStart.SetEnabled(false)
+		m.keyMap.CloseDetails.SetEnabled(false)
+		m.keyMap.CancelWhileEditingFilters.SetEnabled(false)
+		m.keyMap.AcceptWhileEditingFilters.SetEnabled(false)
+		m.keyMap.NextFilterInput.SetEnabled(false)
+		m.keyMap.PrevFilterInput.SetEnabled(false)
+		m.keyMap.ClearFilters.SetEnabled(false)
+		m.keyMap.ShowFullHelp.SetEnabled(false)
+		m.keyMap.CloseFullHelp.SetEnabled(false)
+		m.keyMap.Quit.SetEnabled(false)
+		m.keyMap.ForceQuit.SetEnabled(true)
+
+	case m.filterPanelActive:
+		m.keyMap.CursorUp.SetEnabled(false)
+		m.keyMap.CursorDown.SetEnabled(false)
+		m.keyMap.NextTab.SetEnabled(false)
+		m.keyMap.PrevTab.SetEnabled(false)
+		m.keyMap.GoToStart.SetEnabled(false)
+		m.keyMap.GoToEnd.SetEnabled(false)
+		m.keyMap.Search.SetEnabled(false)
+		m.keyMap.ClearSearch.SetEnabled(false)
+		m.keyMap.Filter.SetEnabled(false)
+		m.keyMap.ClearFilter.SetEnabled(false)
+		m.keyMap.Select.SetEnabled(false)
+		m.keyMap.ShowDetails.SetEnabled(false)
+		m.keyMap.Back.SetEnabled(false)
+		m.keyMap.ToggleWatched.SetEnabled(false)
+		m.keyMap.ToggleFavorite.SetEnabled(false)
+		m.keyMap.Sort.SetEnabled(false)
+		m.keyMap.EditFilters.SetEnabled(false)
+		m.keyMap.Refresh.SetEnabled(false)
+		m.keyMap.SwitchProfile.SetEnabled(false)
+		m.keyMap.CycleQuality.SetEnabled(false)
+		m.keyMap.CancelWhileSearching.SetEnabled(false)
+		m.keyMap.AcceptWhileSearching.SetEnabled(false)
+		m.keyMap.CancelWhileFiltering.SetEnabled(false)
+		m.keyMap.AcceptWhileFiltering.SetEnabled(false)
+		m.keyMap.CancelWhileSorting.SetEnabled(false)
+		m.keyMap.AcceptWhileSorting.SetEnabled(false)
+		m.keyMap.CancelWhilePickingSource.SetEnabled(false)
+		m.keyMap.AcceptWhilePickingSource.SetEnabled(false)
+		m.keyMap.PlayFromStart.SetEnabled(false)
+		m.keyMap.CloseDetails.SetEnabled(false)
+		m.keyMap.CancelWhileEditingFilters.SetEnabled(true)
+		m.keyMap.AcceptWhileEditingFilters.SetEnabled(true)
+		m.keyMap.NextFilterInput.SetEnabled(true)
+		m.keyMap.PrevFilterInput.SetEnabled(true)
+		m.keyMap.ClearFilters.SetEnabled(true)
+		m.keyMap.ShowFullHelp.SetEnabled(false)
+		m.keyMap.CloseFullHelp.SetEnabled(false)
+		m.keyMap.Quit.SetEnabled(false)
+		m.keyMap.ForceQuit.SetEnabled(true)
+
+	case m.sortPickerActive:
+		m.keyMap.CursorUp.SetEnabled(true)
+		m.keyMap.CursorDown.SetEnabled(true)
+		m.keyMap.NextTab.SetEnabled(false)
+		m.keyMap.PrevTab.SetEnabled(false)
+		m.keyMap.GoToStart.SetEnabled(false)
+		m.keyMap.GoToEnd.SetEnabled(false)
+		m.keyMap.Search.SetEnabled(false)
+		m.keyMap.ClearSearch.SetEnabled(false)
+		m.keyMap.Filter.SetEnabled(false)
+		m.keyMap.ClearFilter.SetEnabled(false)
+		m.keyMap.Select.SetEnabled(false)
+		m.keyMap.ShowDetails.SetEnabled(false)
+		m.keyMap.Back.SetEnabled(false)
+		m.keyMap.ToggleWatched.SetEnabled(false)
+		m.keyMap.ToggleFavorite.SetEnabled(false)
+		m.keyMap.Sort.SetEnabled(false)
+		m.keyMap.EditFilters.SetEnabled(false)
+		m.keyMap.Refresh.SetEnabled(false)
+		m.keyMap.SwitchProfile.SetEnabled(false)
+		m.keyMap.CycleQuality.SetEnabled(false)
+		m.keyMap.CancelWhileSearching.SetEnabled(false)
+		m.keyMap.AcceptWhileSearching.SetEnabled(false)
+		m.keyMap.CancelWhileFiltering.SetEnabled(false)
+		m.keyMap.AcceptWhileFiltering.SetEnabled(false)
+		m.keyMap.CancelWhileSorting.SetEnabled(true)
+		m.keyMap.AcceptWhileSorting.SetEnabled(true)
+		m.keyMap.CancelWhilePickingSource.SetEnabled(false)
+		m.keyMap.AcceptWhilePickingSource.SetEnabled(false)
+		m.keyMap.PlayFromStart.SetEnabled(false)
+		m.keyMap.CloseDetails.SetEnabled(false)
+		m.keyMap.CancelWhileEditingFilters.SetEnabled(false)
+		m.keyMap.AcceptWhileEditingFilters.SetEnabled(false)
+		m.keyMap.NextFilterInput.SetEnabled(false)
+		m.keyMap.PrevFilterInput.SetEnabled(false)
+		m.keyMap.ClearFilters.SetEnabled(false)
+		m.keyMap.ShowFullHelp.SetEnabled(false)
+		m.keyMap.CloseFullHelp.SetEnabled(false)
+		m.keyMap.Quit.SetEnabled(false)
+		m.keyMap.ForceQuit.SetEnabled(true)
+
+	case m.sourcePickerActive:
+		m.keyMap.CursorUp.SetEnabled(true)
+		m.keyMap.CursorDown.SetEnabled(true)
+		m.keyMap.NextTab.SetEnabled(false)
+		m.keyMap.PrevTab.SetEnabled(false)
+		m.keyMap.GoToStart.SetEnabled(false)
+		m.keyMap.GoToEnd.SetEnabled(false)
+		m.keyMap.Search.SetEnabled(false)
+		m.keyMap.ClearSearch.SetEnabled(false)
+		m.keyMap.Filter.SetEnabled(false)
+		m.keyMap.ClearFilter.SetEnabled(false)
+		m.keyMap.Select.SetEnabled(false)
+		m.keyMap.ShowDetails.SetEnabled(false)
+		m.keyMap.Back.SetEnabled(false)
+		m.keyMap.ToggleWatched.SetEnabled(false)
+		m.keyMap.ToggleFavorite.SetEnabled(false)
+		m.keyMap.Sort.SetEnabled(false)
+		m.keyMap.EditFilters.SetEnabled(false)
+		m.keyMap.Refresh.SetEnabled(false)
+		m.keyMap.SwitchProfile.SetEnabled(false)
+		m.keyMap.CycleQuality.SetEnabled(false)
+		m.keyMap.CancelWhileSearching.SetEnabled(false)
+		m.keyMap.AcceptWhileSearching.SetEnabled(false)
+		m.keyMap.CancelWhileFiltering.SetEnabled(false)
+		m.keyMap.AcceptWhileFiltering.SetEnabled(false)
+		m.keyMap.CancelWhileSorting.SetEnabled(false)
+		m.keyMap.AcceptWhileSorting.SetEnabled(false)
+		m.keyMap.CancelWhilePickingSource.SetEnabled(true)
+		m.keyMap.AcceptWhilePickingSource.SetEnabled(true)
+		m.keyMap.PlayFromStart.SetEnabled(false)
+		m.keyMap.CloseDetails.SetEnabled(false)
+		m.keyMap.CancelWhileEditingFilters.SetEnabled(false)
+		m.keyMap.AcceptWhileEditingFilters.SetEnabled(false)
+		m.keyMap.NextFilterInput.SetEnabled(false)
+		m.keyMap.PrevFilterInput.SetEnabled(false)
+		m.keyMap.ClearFilters.SetEnabled(false)
 		m.keyMap.ShowFullHelp.SetEnabled(false)
 		m.keyMap.CloseFullHelp.SetEnabled(false)
 		m.keyMap.Quit.SetEnabled(false)
@@ -242,19 +504,77 @@ func (m *model) updateKeys() {
 		m.keyMap.Filter.SetEnabled(false)
 		m.keyMap.ClearFilter.SetEnabled(false)
 		m.keyMap.Select.SetEnabled(false)
+		m.keyMap.ShowDetails.SetEnabled(false)
 		m.keyMap.Back.SetEnabled(false)
 		m.keyMap.ToggleWatched.SetEnabled(false)
+		m.keyMap.ToggleFavorite.SetEnabled(false)
+		m.keyMap.Sort.SetEnabled(false)
+		m.keyMap.EditFilters.SetEnabled(false)
 		m.keyMap.Refresh.SetEnabled(false)
+		m.keyMap.SwitchProfile.SetEnabled(false)
+		m.keyMap.CycleQuality.SetEnabled(false)
 		m.keyMap.CancelWhileSearching.SetEnabled(false)
 		m.keyMap.AcceptWhileSearching.SetEnabled(false)
 		m.keyMap.CancelWhileFiltering.SetEnabled(false)
 		m.keyMap.AcceptWhileFiltering.SetEnabled(false)
+		m.keyMap.CancelWhileSorting.SetEnabled(false)
+		m.keyMap.AcceptWhileSorting.SetEnabled(false)
+		m.keyMap.CancelWhilePickingSource.SetEnabled(false)
+		m.keyMap.AcceptWhilePickingSource.SetEnabled(false)
+		m.keyMap.PlayFromStart.SetEnabled(false)
+		m.keyMap.CloseDetails.SetEnabled(false)
+		m.keyMap.CancelWhileEditingFilters.SetEnabled(false)
+		m.keyMap.AcceptWhileEditingFilters.SetEnabled(false)
+		m.keyMap.NextFilterInput.SetEnabled(false)
+		m.keyMap.PrevFilterInput.SetEnabled(false)
+		m.keyMap.ClearFilters.SetEnabled(false)
 		m.keyMap.ShowFullHelp.SetEnabled(false)
 		m.keyMap.CloseFullHelp.SetEnabled(false)
 		m.keyMap.Quit.SetEnabled(false)
 		m.keyMap.ForceQuit.SetEnabled(false)
 
-	case m.currentSeries != nil:
+	case m.detail != nil:
+		m.keyMap.CursorUp.SetEnabled(false)
+		m.keyMap.CursorDown.SetEnabled(false)
+		m.keyMap.NextTab.SetEnabled(false)
+		m.keyMap.PrevTab.SetEnabled(false)
+		m.keyMap.GoToStart.SetEnabled(false)
+		m.keyMap.GoToEnd.SetEnabled(false)
+		m.keyMap.Search.SetEnabled(false)
+		m.keyMap.ClearSearch.SetEnabled(false)
+		m.keyMap.Filter.SetEnabled(false)
+		m.keyMap.ClearFilter.SetEnabled(false)
+		m.keyMap.Select.SetEnabled(true)
+		m.keyMap.ShowDetails.SetEnabled(false)
+		m.keyMap.Back.SetEnabled(false)
+		m.keyMap.ToggleWatched.SetEnabled(!jellyfin.IsSeries(*m.detail) && !jellyfin.IsSeason(*m.detail) && !jellyfin.IsLibrary(*m.detail))
+		m.keyMap.ToggleFavorite.SetEnabled(!jellyfin.IsLibrary(*m.detail))
+		m.keyMap.Sort.SetEnabled(false)
+		m.keyMap.EditFilters.SetEnabled(false)
+		m.keyMap.Refresh.SetEnabled(false)
+		m.keyMap.SwitchProfile.SetEnabled(false)
+		m.keyMap.CycleQuality.SetEnabled(false)
+		m.keyMap.CancelWhileSearching.SetEnabled(false)
+		m.keyMap.AcceptWhileSearching.SetEnabled(false)
+		m.keyMap.CancelWhileFiltering.SetEnabled(false)
+		m.keyMap.AcceptWhileFiltering.SetEnabled(false)
+		m.keyMap.CancelWhileSorting.SetEnabled(false)
+		m.keyMap.AcceptWhileSorting.SetEnabled(false)
+		m.keyMap.CancelWhilePickingSource.SetEnabled(false)
+		m.keyMap.AcceptWhilePickingSource.SetEnabled(false)
+		m.keyMap.PlayFromStart.SetEnabled(jellyfin.GetResumePosition(*m.detail) > 0)
+		m.keyMap.CloseDetails.SetEnabled(true)
+		m.keyMap.CancelWhileEditingFilters.SetEnabled(false)
+		m.keyMap.AcceptWhileEditingFilters.SetEnabled(false)
+		m.keyMap.NextFilterInput.SetEnabled(false)
+		m.keyMap.PrevFilterInput.SetEnabled(false)
+		m.keyMap.ClearFilters.SetEnabled(false)
+		m.keyMap.ShowFullHelp.SetEnabled(!m.help.ShowAll)
+		m.keyMap.CloseFullHelp.SetEnabled(m.help.ShowAll)
+		m.keyMap.Quit.SetEnabled(true)
+		m.keyMap.ForceQuit.SetEnabled(true)
+
+	case len(m.stack) > 0:
 		m.keyMap.CursorUp.SetEnabled(true)
 		m.keyMap.CursorDown.SetEnabled(true)
 		m.keyMap.NextTab.SetEnabled(false)
@@ -266,13 +586,30 @@ func (m *model) updateKeys() {
 		m.keyMap.Filter.SetEnabled(true)
 		m.keyMap.ClearFilter.SetEnabled(m.filterActive)
 		m.keyMap.Select.SetEnabled(len(m.items) > 0 && m.currentItem < len(m.items))
+		m.keyMap.ShowDetails.SetEnabled(len(m.items) > 0 && m.currentItem < len(m.items))
 		m.keyMap.Back.SetEnabled(true)
-		m.keyMap.ToggleWatched.SetEnabled(len(m.items) > 0 && m.currentItem < len(m.items) && !jellyfin.IsSeries(m.items[m.currentItem]))
+		m.keyMap.ToggleWatched.SetEnabled(len(m.items) > 0 && m.currentItem < len(m.items) && !jellyfin.IsSeries(m.items[m.currentItem]) && !jellyfin.IsSeason(m.items[m.currentItem]) && !jellyfin.IsLibrary(m.items[m.currentItem]))
+		m.keyMap.ToggleFavorite.SetEnabled(len(m.items) > 0 && m.currentItem < len(m.items) && !jellyfin.IsLibrary(m.items[m.currentItem]))
+		m.keyMap.Sort.SetEnabled(m.queryable())
+		m.keyMap.EditFilters.SetEnabled(m.queryable())
 		m.keyMap.Refresh.SetEnabled(true)
+		m.keyMap.SwitchProfile.SetEnabled(true)
+		m.keyMap.CycleQuality.SetEnabled(true)
 		m.keyMap.CancelWhileSearching.SetEnabled(false)
 		m.keyMap.AcceptWhileSearching.SetEnabled(false)
 		m.keyMap.CancelWhileFiltering.SetEnabled(false)
 		m.keyMap.AcceptWhileFiltering.SetEnabled(false)
+		m.keyMap.CancelWhileSorting.SetEnabled(false)
+		m.keyMap.AcceptWhileSorting.SetEnabled(false)
+		m.keyMap.CancelWhilePickingSource.SetEnabled(false)
+		m.keyMap.AcceptWhilePickingSource.SetEnabled(false)
+		m.keyMap.PlayFromStart.SetEnabled(false)
+		m.keyMap.CloseDetails.SetEnabled(false)
+		m.keyMap.CancelWhileEditingFilters.SetEnabled(false)
+		m.keyMap.AcceptWhileEditingFilters.SetEnabled(false)
+		m.keyMap.NextFilterInput.SetEnabled(false)
+		m.keyMap.PrevFilterInput.SetEnabled(false)
+		m.keyMap.ClearFilters.SetEnabled(false)
 		m.keyMap.ShowFullHelp.SetEnabled(!m.help.ShowAll)
 		m.keyMap.CloseFullHelp.SetEnabled(m.help.ShowAll)
 		m.keyMap.Quit.SetEnabled(true)
@@ -290,13 +627,30 @@ func (m *model) updateKeys() {
 		m.keyMap.Filter.SetEnabled(true)
 		m.keyMap.ClearFilter.SetEnabled(m.filterActive)
 		m.keyMap.Select.SetEnabled(len(m.items) > 0 && m.currentItem < len(m.items))
+		m.keyMap.ShowDetails.SetEnabled(len(m.items) > 0 && m.currentItem < len(m.items))
 		m.keyMap.Back.SetEnabled(false)
-		m.keyMap.ToggleWatched.SetEnabled(len(m.items) > 0 && m.currentItem < len(m.items) && !jellyfin.IsSeries(m.items[m.currentItem]))
+		m.keyMap.ToggleWatched.SetEnabled(len(m.items) > 0 && m.currentItem < len(m.items) && !jellyfin.IsSeries(m.items[m.currentItem]) && !jellyfin.IsSeason(m.items[m.currentItem]) && !jellyfin.IsLibrary(m.items[m.currentItem]))
+		m.keyMap.ToggleFavorite.SetEnabled(len(m.items) > 0 && m.currentItem < len(m.items) && !jellyfin.IsLibrary(m.items[m.currentItem]))
+		m.keyMap.Sort.SetEnabled(m.queryable())
+		m.keyMap.EditFilters.SetEnabled(m.queryable())
 		m.keyMap.Refresh.SetEnabled(true)
+		m.keyMap.SwitchProfile.SetEnabled(true)
+		m.keyMap.CycleQuality.SetEnabled(true)
 		m.keyMap.CancelWhileSearching.SetEnabled(false)
 		m.keyMap.AcceptWhileSearching.SetEnabled(false)
 		m.keyMap.CancelWhileFiltering.SetEnabled(false)
 		m.keyMap.AcceptWhileFiltering.SetEnabled(false)
+		m.keyMap.CancelWhileSorting.SetEnabled(false)
+		m.keyMap.AcceptWhileSorting.SetEnabled(false)
+		m.keyMap.CancelWhilePickingSource.SetEnabled(false)
+		m.keyMap.AcceptWhilePickingSource.SetEnabled(false)
+		m.keyMap.PlayFromStart.SetEnabled(false)
+		m.keyMap.CloseDetails.SetEnabled(false)
+		m.keyMap.CancelWhileEditingFilters.SetEnabled(false)
+		m.keyMap.AcceptWhileEditingFilters.SetEnabled(false)
+		m.keyMap.NextFilterInput.SetEnabled(false)
+		m.keyMap.PrevFilterInput.SetEnabled(false)
+		m.keyMap.ClearFilters.SetEnabled(false)
 		m.keyMap.ShowFullHelp.SetEnabled(!m.help.ShowAll)
 		m.keyMap.CloseFullHelp.SetEnabled(m.help.ShowAll)
 		m.keyMap.Quit.SetEnabled(true)
@@ -314,13 +668,30 @@ func (m *model) updateKeys() {
 		m.keyMap.Filter.SetEnabled(false)
 		m.keyMap.ClearFilter.SetEnabled(false)
 		m.keyMap.Select.SetEnabled(len(m.items) > 0 && m.currentItem < len(m.items))
+		m.keyMap.ShowDetails.SetEnabled(len(m.items) > 0 && m.currentItem < len(m.items))
 		m.keyMap.Back.SetEnabled(false)
-		m.keyMap.ToggleWatched.SetEnabled(len(m.items) > 0 && m.currentItem < len(m.items) && !jellyfin.IsSeries(m.items[m.currentItem]))
+		m.keyMap.ToggleWatched.SetEnabled(len(m.items) > 0 && m.currentItem < len(m.items) && !jellyfin.IsSeries(m.items[m.currentItem]) && !jellyfin.IsSeason(m.items[m.currentItem]) && !jellyfin.IsLibrary(m.items[m.currentItem]))
+		m.keyMap.ToggleFavorite.SetEnabled(len(m.items) > 0 && m.currentItem < len(m.items) && !jellyfin.IsLibrary(m.items[m.currentItem]))
+		m.keyMap.Sort.SetEnabled(m.queryable())
+		m.keyMap.EditFilters.SetEnabled(m.queryable())
 		m.keyMap.Refresh.SetEnabled(true)
+		m.keyMap.SwitchProfile.SetEnabled(true)
+		m.keyMap.CycleQuality.SetEnabled(true)
 		m.keyMap.CancelWhileSearching.SetEnabled(false)
 		m.keyMap.AcceptWhileSearching.SetEnabled(false)
 		m.keyMap.CancelWhileFiltering.SetEnabled(false)
 		m.keyMap.AcceptWhileFiltering.SetEnabled(false)
+		m.keyMap.CancelWhileSorting.SetEnabled(false)
+		m.keyMap.AcceptWhileSorting.SetEnabled(false)
+		m.keyMap.CancelWhilePickingSource.SetEnabled(false)
+		m.keyMap.AcceptWhilePickingSource.SetEnabled(false)
+		m.keyMap.PlayFromStart.SetEnabled(false)
+		m.keyMap.CloseDetails.SetEnabled(false)
+		m.keyMap.CancelWhileEditingFilters.SetEnabled(false)
+		m.keyMap.AcceptWhileEditingFilters.SetEnabled(false)
+		m.keyMap.NextFilterInput.SetEnabled(false)
+		m.keyMap.PrevFilterInput.SetEnabled(false)
+		m.keyMap.ClearFilters.SetEnabled(false)
 		m.keyMap.ShowFullHelp.SetEnabled(!m.help.ShowAll)
 		m.keyMap.CloseFullHelp.SetEnabled(m.help.ShowAll)
 		m.keyMap.Quit.SetEnabled(true)
@@ -338,13 +709,30 @@ func (m *model) updateKeys() {
 		m.keyMap.Filter.SetEnabled(false)
 		m.keyMap.ClearFilter.SetEnabled(false)
 		m.keyMap.Select.SetEnabled(false)
+		m.keyMap.ShowDetails.SetEnabled(false)
 		m.keyMap.Back.SetEnabled(false)
 		m.keyMap.ToggleWatched.SetEnabled(false)
+		m.keyMap.ToggleFavorite.SetEnabled(false)
+		m.keyMap.Sort.SetEnabled(false)
+		m.keyMap.EditFilters.SetEnabled(false)
 		m.keyMap.Refresh.SetEnabled(false)
+		m.keyMap.SwitchProfile.SetEnabled(false)
+		m.keyMap.CycleQuality.SetEnabled(false)
 		m.keyMap.CancelWhileSearching.SetEnabled(true)
 		m.keyMap.AcceptWhileSearching.SetEnabled(true)
 		m.keyMap.CancelWhileFiltering.SetEnabled(false)
 		m.keyMap.AcceptWhileFiltering.SetEnabled(false)
+		m.keyMap.CancelWhileSorting.SetEnabled(false)
+		m.keyMap.AcceptWhileSorting.SetEnabled(false)
+		m.keyMap.CancelWhilePickingSource.SetEnabled(false)
+		m.keyMap.AcceptWhilePickingSource.SetEnabled(false)
+		m.keyMap.PlayFromStart.SetEnabled(false)
+		m.keyMap.CloseDetails.SetEnabled(false)
+		m.keyMap.CancelWhileEditingFilters.SetEnabled(false)
+		m.keyMap.AcceptWhileEditingFilters.SetEnabled(false)
+		m.keyMap.NextFilterInput.SetEnabled(false)
+		m.keyMap.PrevFilterInput.SetEnabled(false)
+		m.keyMap.ClearFilters.SetEnabled(false)
 		m.keyMap.ShowFullHelp.SetEnabled(false)
 		m.keyMap.CloseFullHelp.SetEnabled(false)
 		m.keyMap.Quit.SetEnabled(false)
@@ -1,14 +1,19 @@
 package main
 
 import (
+	"fmt"
 	"io"
 	"log"
 	"log/slog"
+	"os"
 	"path/filepath"
 	"runtime/debug"
+	"slices"
+	"strings"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/hacel/jfsh/internal/config"
+	"github.com/hacel/jfsh/internal/jellyfin"
 
 	"github.com/adrg/xdg"
 	"github.com/spf13/pflag"
@@ -29,16 +34,21 @@ func main() {
 	}
 
 	cfgPath := pflag.StringP("config", "c", filepath.Join(xdg.ConfigHome, "jfsh", "jfsh.yaml"), "config file path")
+	profile := pflag.StringP("profile", "p", "", "profile to use for this run (default: the last one picked)")
 	debugPath := pflag.StringP("debug", "d", "", "debug log file path (enables debug logging)")
+	output := pflag.StringP("output", "o", outputPlain, "output format of commands listing items ("+strings.Join(outputFormats, ", ")+")")
+	quality := pflag.StringP("quality", "q", "", "quality profile to play items with (default: the quality set in the config file)")
 	printVersion := pflag.BoolP("version", "v", false, "show version")
 	help := pflag.BoolP("help", "h", false, "show help")
 	pflag.Parse()
 
 	if *help {
-		println("Usage:  jfsh [OPTIONS]")
+		println("Usage:  jfsh [OPTIONS] [COMMAND]")
 		println()
 		println("Options:")
 		pflag.PrintDefaults()
+		println()
+		println(commandsUsage)
 		return
 	}
 
@@ -64,16 +74,68 @@ func main() {
 		log.SetOutput(io.Discard)
 	}
 
-	// first off, run a side bubbletea model that takes care of configuration and initializing the api client
-	client := config.Run(version, *cfgPath)
-	if client == nil {
-		// err handling should happen inside the config model, this means the user quit
+	// commands run without the TUI, so the configuration has to be complete already
+	if args := pflag.Args(); len(args) > 0 {
+		if !slices.Contains(outputFormats, *output) {
+			fmt.Fprintf(os.Stderr, "unknown output format %q\n", *output)
+			os.Exit(1)
+		}
+		client, err := config.Load(version, *cfgPath, *profile)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "failed to load configuration, run jfsh without a command to set it up:", err)
+			os.Exit(1)
+		}
+		if err := validateQuality(quality); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		if err := runCommand(client, args, *output, *quality); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
 		return
 	}
 
-	// now we can run the main bubbletea model
-	p := tea.NewProgram(initialModel(client), tea.WithAltScreen())
-	if _, err := p.Run(); err != nil {
-		panic(err)
+	pick := false
+	for {
+		// first off, run a side bubbletea model that takes care of configuration and initializing the api client
+		var client *jellyfin.Client
+		if pick {
+			client = config.Pick(version, *cfgPath)
+		} else {
+			client = config.Run(version, *cfgPath, *profile)
+		}
+		if client == nil {
+			// err handling should happen inside the config model, this means the user quit
+			return
+		}
+		if err := validateQuality(quality); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+
+		// now we can run the main bubbletea model
+		p := tea.NewProgram(initialModel(client, *quality), tea.WithAltScreen())
+		// requests run in the background, the new token is saved from the main loop that reads the config as well
+		client.TokenChanged = func(token string) {
+			p.Send(tokenChanged{token})
+		}
+		m, err := p.Run()
+		if err != nil {
+			panic(err)
+		}
+		switch {
+		case m.(model).reauthenticate:
+			// go back to the config form if the token got rejected and logging in again wasn't possible
+			pick = false
+		case m.(model).switchProfile:
+			pick = true
+			// from now on the profile last picked is the one to use
+			*profile = ""
+		default:
+			return
+		}
+		// and the quality stays the one picked last
+		*quality = m.(model).quality
 	}
 }
@@ -1,11 +1,16 @@
 package main
 
 import (
+	"log/slog"
+
 	"github.com/charmbracelet/bubbles/help"
 	"github.com/charmbracelet/bubbles/spinner"
 	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/hacel/jfsh/internal/config"
+	"github.com/hacel/jfsh/internal/graphics"
 	"github.com/hacel/jfsh/internal/jellyfin"
+	"github.com/spf13/viper"
 )
 
 type tab int
@@ -14,13 +19,36 @@ const (
 	Resume tab = iota
 	NextUp
 	RecentlyAdded
+	Favorites
+	Libraries
 	Search
 	ResumeTabName        = "Resume"
 	NextUpTabName        = "Next Up"
 	RecentlyAddedTabName = "Recently Added"
+	FavoritesTabName     = "Favorites"
+	LibrariesTabName     = "Libraries"
 	SearchTabName        = "Search"
 )
 
+var tabNames = []string{ResumeTabName, NextUpTabName, RecentlyAddedTabName, FavoritesTabName, LibrariesTabName, SearchTabName}
+
+// keys under which per-tab settings are stored in the config file
+var tabKeys = []string{"resume", "next_up", "recently_added", "favorites", "libraries", "search"}
+
+// number of items requested at a time from paginated lists
+const pageSize = 100
+
+// frame is a level of the navigation stack. It holds the item being browsed and the state of the list it was selected from, so that going back restores the list exactly as it was.
+type frame struct {
+	item jellyfin.Item
+
+	items        []jellyfin.Item
+	totalItems   int
+	currentItem  int
+	filterActive bool
+	filterText   string
+}
+
 type model struct {
 	keyMap KeyMap
 	help   help.Model
@@ -35,21 +63,52 @@ type model struct {
 
 	items       []jellyfin.Item
 	allItems    []jellyfin.Item
+	totalItems  int // total number of items on the server, more than len(allItems) if the list is paginated
 	currentItem int
 
 	filterActive bool
 	filterInput  textinput.Model
+	highlights   map[string][]int // rune indices of each filtered item's title that matched the filter, by item id
 
-	currentSeries *jellyfin.Item
+	sorts            map[tab]jellyfin.Sort // sort order picked for each tab, the list's default order if missing
+	sortPickerActive bool
+	sortCursor       int
 
+	filters            map[tab]jellyfin.Filter // server-side filter of each tab
+	filterPanelActive  bool
+	filterInputs       []textinput.Model
+	currentFilterInput int
+	filterOptions      jellyfin.FilterOptions // genres and ratings available in the current list, shown as hints in the filter panel
+
+	// items that have been drilled into, e.g. library -> collection -> series -> season
+	stack []frame
+
+	detail  *jellyfin.Item // item shown in the detail view
 	playing *jellyfin.Item
 
-	err     error
-	spinner spinner.Model
-	loading bool
+	// versions of sourceItem to pick from before playing it
+	sourcesPending     bool // set while the versions are being fetched
+	sourcePickerActive bool
+	sourceItem         jellyfin.Item
+	sourceFromStart    bool
+	sources            []jellyfin.MediaSource
+	sourceCursor       int
+
+	quality string // quality profile items are played with
+
+	images    graphics.Protocol // how posters are drawn, not at all if none
+	posterKey string            // image being fetched by fetchPoster
+	poster    *poster
+
+	err            error
+	reauthenticate bool // set when quitting because the server rejected the token, to go back to the config form
+	switchProfile  bool // set when quitting to go to the profile picker
+	spinner        spinner.Model
+	loading        bool
+	generation     int // incremented whenever the list is replaced, to drop the results of fetches of an older list
 }
 
-func initialModel(client *jellyfin.Client) model {
+func initialModel(client *jellyfin.Client, quality string) model {
 	searchInput := textinput.New()
 	searchInput.Prompt = "Search: "
 	searchInput.Width = 40
@@ -59,18 +118,47 @@ func initialModel(client *jellyfin.Client) model {
 	filterInput.Width = 40
 
 	m := model{
-		keyMap:      defaultKeyMap(),
-		help:        help.New(),
-		client:      client,
-		searchInput: searchInput,
-		filterInput: filterInput,
-		spinner:     spinner.New(spinner.WithSpinner(spinner.Dot)),
-		loading:     true,
+		keyMap:       defaultKeyMap(),
+		help:         help.New(),
+		client:       client,
+		quality:      quality,
+		searchInput:  searchInput,
+		filterInput:  filterInput,
+		sorts:        loadSorts(),
+		filters:      make(map[tab]jellyfin.Filter),
+		filterInputs: newFilterInputs(),
+		images:       graphics.ParseProtocol(viper.GetString("images")),
+		spinner:      spinner.New(spinner.WithSpinner(spinner.Dot)),
+		loading:      true,
 	}
 	m.updateKeys()
 	return m
 }
 
+// loadSorts reads the sort order of each tab from the config file
+func loadSorts() map[tab]jellyfin.Sort {
+	sorts := make(map[tab]jellyfin.Sort)
+	for i, key := range tabKeys {
+		by, order := viper.GetString("sort."+key+".by"), viper.GetString("sort."+key+".order")
+		if by == "" {
+			continue
+		}
+		sort, ok := jellyfin.ParseSort(by, order)
+		if !ok {
+			slog.Error("invalid sort in config", "tab", key, "by", by, "order", order)
+			continue
+		}
+		sorts[tab(i)] = sort
+	}
+	return sorts
+}
+
+// saveSort writes the sort order of t to the config file
+func saveSort(t tab, sort jellyfin.Sort) error {
+	viper.Set("sort."+tabKeys[t], map[string]string{"by": string(sort.By), "order": string(sort.Order)})
+	return config.Write()
+}
+
 func (m model) Init() tea.Cmd {
 	return tea.Batch(
 		m.fetchItems(),
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/hacel/jfsh/internal/jellyfin"
+)
+
+// formats item lists can be printed in by commands
+const (
+	outputPlain = "plain"
+	outputTSV   = "tsv"
+	outputJSON  = "json"
+)
+
+var outputFormats = []string{outputPlain, outputTSV, outputJSON}
+
+// outputItem is an item as printed by commands. The order of the fields is the order of the tsv columns and must not
+// change, so that scripts relying on it keep working.
+type outputItem struct {
+	ID            string `json:"id"`
+	Type          string `json:"type"`
+	Title         string `json:"title"`
+	Description   string `json:"description"`
+	Name          string `json:"name"`
+	Year          int32  `json:"year"`
+	SeriesID      string `json:"series_id"`
+	SeriesName    string `json:"series_name"`
+	Season        int32  `json:"season"`
+	Episode       int32  `json:"episode"`
+	RuntimeTicks  int64  `json:"runtime_ticks"`
+	PositionTicks int64  `json:"position_ticks"`
+	Played        bool   `json:"played"`
+	Favorite      bool   `json:"favorite"`
+}
+
+func newOutputItem(item jellyfin.Item) outputItem {
+	return outputItem{
+		ID:            item.GetId(),
+		Type:          string(item.GetType()),
+		Title:         jellyfin.GetItemTitle(item),
+		Description:   jellyfin.GetItemDescription(item),
+		Name:          item.GetName(),
+		Year:          item.GetProductionYear(),
+		SeriesID:      item.GetSeriesId(),
+		SeriesName:    item.GetSeriesName(),
+		Season:        item.GetParentIndexNumber(),
+		Episode:       item.GetIndexNumber(),
+		RuntimeTicks:  item.GetRunTimeTicks(),
+		PositionTicks: jellyfin.GetResumePosition(item),
+		Played:        jellyfin.Watched(item),
+		Favorite:      jellyfin.Favorite(item),
+	}
+}
+
+// columns returns the fields of the item in tsv column order
+func (o outputItem) columns() []string {
+	return []string{
+		o.ID,
+		o.Type,
+		o.Title,
+		o.Description,
+		o.Name,
+		strconv.Itoa(int(o.Year)),
+		o.SeriesID,
+		o.SeriesName,
+		strconv.Itoa(int(o.Season)),
+		strconv.Itoa(int(o.Episode)),
+		strconv.FormatInt(o.RuntimeTicks, 10),
+		strconv.FormatInt(o.PositionTicks, 10),
+		strconv.FormatBool(o.Played),
+		strconv.FormatBool(o.Favorite),
+	}
+}
+
+// printItems writes items to w in the given format. Plain output is meant to be read, the others to be parsed.
+func printItems(w io.Writer, items []jellyfin.Item, format string) error {
+	switch format {
+	case outputJSON:
+		out := make([]outputItem, 0, len(items))
+		for _, item := range items {
+			out = append(out, newOutputItem(item))
+		}
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(out)
+
+	case outputTSV:
+		// tabs and newlines inside of values would break the columns
+		clean := strings.NewReplacer("\t", " ", "\n", " ", "\r", " ")
+		for _, item := range items {
+			columns := newOutputItem(item).columns()
+			for i, c := range columns {
+				columns[i] = clean.Replace(c)
+			}
+			if _, err := fmt.Fprintln(w, strings.Join(columns, "\t")); err != nil {
+				return err
+			}
+		}
+		return nil
+
+	case outputPlain:
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		for _, item := range items {
+			fmt.Fprintf(tw, "%s\t%s\t%s\n", item.GetId(), jellyfin.GetItemTitle(item), jellyfin.GetItemDescription(item))
+		}
+		return tw.Flush()
+
+	default:
+		return fmt.Errorf("unknown output format %q", format)
+	}
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/hacel/jfsh/internal/jellyfin"
+	"github.com/sj14/jellyfin-go/api"
+)
+
+func testItem() jellyfin.Item {
+	item := jellyfin.Item{}
+	item.SetId("ep1")
+	item.SetType(api.BASEITEMKIND_EPISODE)
+	item.SetName("Pilot\tPart 1")
+	item.SetProductionYear(2019)
+	item.SetSeriesId("s1")
+	item.SetSeriesName("Show")
+	item.SetParentIndexNumber(1)
+	item.SetIndexNumber(2)
+	item.SetRunTimeTicks(36_000_000_000)
+	data := api.UserItemDataDto{}
+	data.SetPlaybackPositionTicks(600_000_000)
+	data.SetPlayed(false)
+	data.SetIsFavorite(true)
+	item.SetUserData(data)
+	return item
+}
+
+// the columns are relied on by scripts, so their order must not change
+func TestPrintItems(t *testing.T) {
+	tests := []struct {
+		format string
+		want   string
+	}{
+		{
+			format: outputTSV,
+			want:   "ep1\tEpisode\tShow S01E02 (2019) ♥\tPilot Part 1\tPilot Part 1\t2019\ts1\tShow\t1\t2\t36000000000\t600000000\tfalse\ttrue\n",
+		},
+		{
+			format: outputJSON,
+			want: `[
+  {
+    "id": "ep1",
+    "type": "Episode",
+    "title": "Show S01E02 (2019) ♥",
+    "description": "Pilot\tPart 1",
+    "name": "Pilot\tPart 1",
+    "year": 2019,
+    "series_id": "s1",
+    "series_name": "Show",
+    "season": 1,
+    "episode": 2,
+    "runtime_ticks": 36000000000,
+    "position_ticks": 600000000,
+    "played": false,
+    "favorite": true
+  }
+]
+`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := printItems(&buf, []jellyfin.Item{testItem()}, tt.format); err != nil {
+				t.Fatal(err)
+			}
+			if got := buf.String(); got != tt.want {
+				t.Errorf("printItems() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"bytes"
+	"image"
+	_ "image/jpeg"
+	_ "image/png"
+	"log/slog"
+	"os"
+	"path/filepath"
+
+	"github.com/adrg/xdg"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/hacel/jfsh/internal/graphics"
+	"github.com/hacel/jfsh/internal/jellyfin"
+)
+
+// height in pixels images are downloaded at, enough for a poster filling most of a terminal
+const posterHeight = 720
+
+// lists narrower than this don't show the poster of the selected item
+const posterMinListWidth = 100
+
+// poster is a downloaded image along with its last rendering, so that it isn't encoded again on every frame
+type poster struct {
+	key string
+	img image.Image
+
+	protocol   graphics.Protocol
+	cols, rows int
+	rendered   string
+}
+
+// render returns the image drawn in at most maxCols by maxRows cells and the size it actually takes
+func (p *poster) render(protocol graphics.Protocol, maxCols, maxRows int) (string, int, int) {
+	cols, rows := graphics.Fit(p.img, maxCols, maxRows)
+	if p.protocol != protocol || p.cols != cols || p.rows != rows {
+		p.protocol, p.cols, p.rows = protocol, cols, rows
+		p.rendered = graphics.Render(protocol, p.img, cols, rows)
+	}
+	return p.rendered, cols, rows
+}
+
+type fetchPosterResult struct {
+	key string
+	img image.Image
+	err error
+}
+
+func posterKey(img jellyfin.Image) string {
+	return img.ItemID + "-" + img.Type + "-" + img.Tag
+}
+
+// posterItem returns the item whose poster should be on screen, if any
+func (m model) posterItem() (jellyfin.Item, bool) {
+	switch {
+	case m.images == graphics.None, m.playing != nil, m.filterPanelActive, m.sortPickerActive, m.sourcePickerActive:
+		return jellyfin.Item{}, false
+	case m.detail != nil:
+		return *m.detail, true
+	case len(m.items) > 0 && m.width >= posterMinListWidth:
+		return m.items[m.currentItem], true
+	}
+	return jellyfin.Item{}, false
+}
+
+// currentPoster returns the poster of the item on screen if it has been fetched
+func (m model) currentPoster() (*poster, bool) {
+	item, ok := m.posterItem()
+	if !ok || m.poster == nil {
+		return nil, false
+	}
+	img, ok := jellyfin.GetItemImage(item)
+	if !ok || posterKey(img) != m.poster.key {
+		return nil, false
+	}
+	return m.poster, true
+}
+
+// fetchPoster fetches the poster of the item on screen, from the image cache if it has been fetched before
+func (m *model) fetchPoster() tea.Cmd {
+	item, ok := m.posterItem()
+	if !ok {
+		return nil
+	}
+	img, ok := jellyfin.GetItemImage(item)
+	if !ok {
+		return nil
+	}
+	key := posterKey(img)
+	if key == m.posterKey || (m.poster != nil && m.poster.key == key) {
+		return nil
+	}
+	m.posterKey = key
+	return func() tea.Msg {
+		// the tag changes whenever the image does, so cached images never need to be invalidated
+		path, err := xdg.CacheFile(filepath.Join("jfsh", "images", key+".jpg"))
+		if err != nil {
+			return fetchPosterResult{key: key, err: err}
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			data, err = m.client.GetImage(img, posterHeight)
+			if err != nil {
+				return fetchPosterResult{key: key, err: err}
+			}
+			if err := os.WriteFile(path, data, 0o644); err != nil {
+				slog.Error("failed to cache image", "path", path, "err", err)
+			}
+		}
+		decoded, _, err := image.Decode(bytes.NewReader(data))
+		return fetchPosterResult{key: key, img: decoded, err: err}
+	}
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/hacel/jfsh/internal/jellyfin"
+	"github.com/spf13/viper"
+)
+
+// quality that plays files as they are, unless the server requires a transcode
+const originalQuality = "original"
+
+// qualities returns the original quality followed by the names of the quality profiles in the config file
+func qualities() []string {
+	profiles, _ := viper.AllSettings()["qualities"].(map[string]any)
+	names := slices.Sorted(maps.Keys(profiles))
+	names = slices.DeleteFunc(names, func(name string) bool { return name == originalQuality })
+	return append([]string{originalQuality}, names...)
+}
+
+// defaultQuality returns the quality set in the config file, or the original quality
+func defaultQuality() string {
+	if quality := viper.GetString("quality"); slices.Contains(qualities(), quality) {
+		return quality
+	}
+	return originalQuality
+}
+
+// validateQuality makes sure the quality picked with --quality exists, falling back to the default quality if none was
+func validateQuality(quality *string) error {
+	if *quality == "" {
+		*quality = defaultQuality()
+		return nil
+	}
+	if !slices.Contains(qualities(), *quality) {
+		return fmt.Errorf("unknown quality %q, the qualities are: %s", *quality, strings.Join(qualities(), ", "))
+	}
+	return nil
+}
+
+// playbackOptions returns the options of the quality profile called quality
+func playbackOptions(quality string) jellyfin.PlaybackOptions {
+	opts := jellyfin.PlaybackOptions{
+		Transcode: viper.GetBool("transcode"),
+		Tracks: jellyfin.TrackPreferences{
+			AudioLanguage:    viper.GetString("audio_language"),
+			SubtitleLanguage: viper.GetString("subtitle_language"),
+			SubtitleMode:     viper.GetString("subtitle_mode"),
+		},
+	}
+	if quality == originalQuality {
+		return opts
+	}
+	key := "qualities." + quality + "."
+	opts.Transcode = opts.Transcode || viper.GetBool(key+"transcode")
+	opts.MaxWidth = viper.GetInt(key + "max_width")
+	opts.MaxHeight = viper.GetInt(key + "max_height")
+	opts.MaxBitrate = viper.GetInt(key + "max_bitrate")
+	opts.VideoCodecs = viper.GetStringSlice(key + "video_codecs")
+	opts.AudioCodecs = viper.GetStringSlice(key + "audio_codecs")
+	opts.AudioChannels = viper.GetInt(key + "audio_channels")
+	return opts
+}
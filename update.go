@@ -1,12 +1,14 @@
 package main
 
 import (
+	"errors"
+	"log/slog"
 	"slices"
-	"strings"
 
 	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/spinner"
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/hacel/jfsh/internal/config"
 	"github.com/hacel/jfsh/internal/jellyfin"
 	"github.com/hacel/jfsh/internal/mpv"
 )
@@ -15,42 +17,86 @@ type playbackStopped struct {
 	err error
 }
 
-func (m *model) playItem() tea.Cmd {
-	client := m.client
-	item := m.items[m.currentItem]
-	if jellyfin.IsEpisode(item) {
-		return func() tea.Msg {
-			// get all episodes of the series and find the index of selected episode
-			items, err := client.GetEpisodes(item)
-			if err != nil {
-				return err
-			}
-			idx := slices.IndexFunc(items, func(i jellyfin.Item) bool {
-				return item.GetId() == i.GetId()
-			})
-			idx = max(0, idx) // sanity check
-			if err := mpv.Play(client, items, idx); err != nil {
-				return playbackStopped{err}
-			}
-			return playbackStopped{nil}
-		}
+// play plays item in mpv, queueing the rest of the series if it's an episode. If fromStart is set the resume position is ignored.
+func play(client *jellyfin.Client, item jellyfin.Item, fromStart bool, mediaSourceID string, opts jellyfin.PlaybackOptions) error {
+	if fromStart {
+		item = jellyfin.WithoutResumePosition(item)
+	}
+	if !jellyfin.IsEpisode(item) {
+		return mpv.Play(client, []jellyfin.Item{item}, 0, mediaSourceID, opts)
+	}
+	// get all episodes of the series and find the index of selected episode
+	items, err := client.GetEpisodes(item)
+	if err != nil {
+		return err
+	}
+	idx := slices.IndexFunc(items, func(i jellyfin.Item) bool {
+		return item.GetId() == i.GetId()
+	})
+	idx = max(0, idx) // sanity check
+	if fromStart {
+		items[idx] = jellyfin.WithoutResumePosition(items[idx])
 	}
+	return mpv.Play(client, items, idx, mediaSourceID, opts)
+}
+
+// playItem plays the media source of item with id mediaSourceID, or its default one if empty, in the background and
+// reports back once playback stops
+func (m *model) playItem(item jellyfin.Item, fromStart bool, mediaSourceID string) tea.Cmd {
+	m.playing = &item
+	client, opts := m.client, playbackOptions(m.quality)
 	return func() tea.Msg {
-		if err := mpv.Play(client, []jellyfin.Item{item}, 0); err != nil {
-			return playbackStopped{err}
-		}
-		return playbackStopped{nil}
+		return playbackStopped{play(client, item, fromStart, mediaSourceID, opts)}
+	}
+}
+
+// tokenChanged is sent once the client logged in again
+type tokenChanged struct {
+	token string
+}
+
+type mediaSourcesResult struct {
+	item      jellyfin.Item
+	fromStart bool
+	sources   []jellyfin.MediaSource
+	err       error
+}
+
+// startPlayback plays item, letting the user pick which version first if it has several. It does nothing while the
+// versions of an item are still being fetched, so that pressing play twice doesn't play twice.
+func (m *model) startPlayback(item jellyfin.Item, fromStart bool) tea.Cmd {
+	if m.sourcesPending {
+		return nil
+	}
+	if !jellyfin.HasMultipleVersions(item) {
+		return m.playItem(item, fromStart, "")
+	}
+	m.loading = true
+	m.sourcesPending = true
+	client := m.client
+	return func() tea.Msg {
+		sources, err := client.GetMediaSources(item)
+		return mediaSourcesResult{item, fromStart, sources, err}
+	}
+}
+
+// selectItem drills into item if it has children, or plays it otherwise
+func (m *model) selectItem(item jellyfin.Item) tea.Cmd {
+	if jellyfin.IsSeries(item) || jellyfin.IsSeason(item) || jellyfin.IsLibrary(item) {
+		m.detail = nil
+		m.pushFrame(item)
+		return m.fetchItems()
 	}
+	return m.startPlayback(item, false)
 }
 
 type toggleWatchedResult struct {
 	err error
 }
 
-func (m *model) toggleWatchedStatus() tea.Cmd {
+func (m *model) toggleWatchedStatus(item jellyfin.Item) tea.Cmd {
 	m.loading = true
 	client := m.client
-	item := m.items[m.currentItem]
 	if jellyfin.Watched(item) {
 		return func() tea.Msg {
 			if err := client.MarkAsUnwatched(item); err != nil {
@@ -68,66 +114,292 @@ func (m *model) toggleWatchedStatus() tea.Cmd {
 	}
 }
 
+type toggleFavoriteResult struct {
+	err error
+}
+
+func (m *model) toggleFavoriteStatus(item jellyfin.Item) tea.Cmd {
+	m.loading = true
+	client := m.client
+	if jellyfin.Favorite(item) {
+		return func() tea.Msg {
+			if err := client.UnmarkFavorite(item); err != nil {
+				return toggleFavoriteResult{err}
+			}
+			return toggleFavoriteResult{nil}
+		}
+	} else {
+		return func() tea.Msg {
+			if err := client.MarkFavorite(item); err != nil {
+				return toggleFavoriteResult{err}
+			}
+			return toggleFavoriteResult{nil}
+		}
+	}
+}
+
+type fetchDetailResult struct {
+	item jellyfin.Item
+	err  error
+}
+
+// fetchDetail fetches all fields of the item shown in the detail view
+func (m *model) fetchDetail() tea.Cmd {
+	if m.detail == nil {
+		return nil
+	}
+	client := m.client
+	item := *m.detail
+	return func() tea.Msg {
+		item, err := client.GetItem(item)
+		return fetchDetailResult{item, err}
+	}
+}
+
+// parent returns the item at the top of the navigation stack
+func (m model) parent() (jellyfin.Item, bool) {
+	if len(m.stack) == 0 {
+		return jellyfin.Item{}, false
+	}
+	return m.stack[len(m.stack)-1].item, true
+}
+
+// pushFrame saves the state of the current list and drills into item
+func (m *model) pushFrame(item jellyfin.Item) {
+	m.stack = append(m.stack, frame{
+		item:         item,
+		items:        m.allItems,
+		totalItems:   m.totalItems,
+		currentItem:  m.currentItem,
+		filterActive: m.filterActive,
+		filterText:   m.filterInput.Value(),
+	})
+	m.allItems = nil
+	m.items = nil
+	m.totalItems = 0
+	m.currentItem = 0
+	m.filterInput.SetValue("")
+	m.filterActive = false
+}
+
+// popFrame goes back to the previous list, restoring it as it was when pushFrame was called
+func (m *model) popFrame() {
+	if len(m.stack) == 0 {
+		return
+	}
+	f := m.stack[len(m.stack)-1]
+	m.stack = m.stack[:len(m.stack)-1]
+	// the restored list must not be replaced by a fetch of the one being left
+	m.generation++
+	m.loading = false
+	m.allItems = f.items
+	m.totalItems = f.totalItems
+	m.filterActive = f.filterActive
+	m.filterInput.SetValue(f.filterText)
+	m.applyFilter()
+	m.currentItem = min(f.currentItem, max(len(m.items)-1, 0))
+}
+
 type fetchItemsResult struct {
-	items []jellyfin.Item
-	err   error
+	items      []jellyfin.Item
+	total      int
+	generation int
+	err        error
 }
 
+// fetchItems fetches the first page of the current list, dropping the results of fetches started before it
 func (m *model) fetchItems() tea.Cmd {
 	m.loading = true
+	m.generation++
+	generation := m.generation
+	fetch := m.itemsFetcher()
+	return func() tea.Msg {
+		items, total, err := fetch()
+		if err != nil {
+			return fetchItemsResult{nil, 0, generation, err}
+		}
+		return fetchItemsResult{items, total, generation, nil}
+	}
+}
+
+// itemsFetcher returns a function that fetches the first page of the current list and the total number of items in it
+func (m model) itemsFetcher() func() ([]jellyfin.Item, int, error) {
 	client := m.client
-	if m.currentSeries != nil {
-		return func() tea.Msg {
-			items, err := client.GetEpisodes(*m.currentSeries)
-			if err != nil {
-				return fetchItemsResult{nil, err}
+	if fetchPage := m.pageFetcher(); fetchPage != nil {
+		return func() ([]jellyfin.Item, int, error) {
+			return fetchPage(0)
+		}
+	}
+	if parent, ok := m.parent(); ok {
+		switch {
+		case jellyfin.IsSeason(parent):
+			return func() ([]jellyfin.Item, int, error) {
+				return all(client.GetEpisodes(parent))
+			}
+		case jellyfin.IsSeries(parent):
+			return func() ([]jellyfin.Item, int, error) {
+				return all(client.GetSeasons(parent))
 			}
-			return fetchItemsResult{items, nil}
 		}
 	}
 	switch m.currentTab {
 	case Resume:
-		return func() tea.Msg {
-			items, err := client.GetResume()
-			if err != nil {
-				return fetchItemsResult{nil, err}
-			}
-			return fetchItemsResult{items, nil}
+		return func() ([]jellyfin.Item, int, error) {
+			return all(client.GetResume())
 		}
 	case NextUp:
-		return func() tea.Msg {
-			items, err := client.GetNextUp()
-			if err != nil {
-				return fetchItemsResult{nil, err}
-			}
-			return fetchItemsResult{items, nil}
+		return func() ([]jellyfin.Item, int, error) {
+			return all(client.GetNextUp())
+		}
+	case Libraries:
+		return func() ([]jellyfin.Item, int, error) {
+			return all(client.GetViews())
 		}
+	default:
+		panic("oops, selected tab is not in switch statement")
+	}
+}
+
+// all returns items along with their count, for lists that are fetched at once
+func all(items []jellyfin.Item, err error) ([]jellyfin.Item, int, error) {
+	return items, len(items), err
+}
+
+type fetchMoreItemsResult struct {
+	items      []jellyfin.Item
+	startIndex int
+	total      int
+	generation int
+	err        error
+}
+
+// pageFetcher returns a function that fetches a page of the current list starting at startIndex, or nil if the list is not paginated
+func (m model) pageFetcher() func(startIndex int) ([]jellyfin.Item, int, error) {
+	client := m.client
+	sort, filter := m.sorts[m.currentTab], m.filters[m.currentTab]
+	if parent, ok := m.parent(); ok {
+		if !jellyfin.IsLibrary(parent) {
+			return nil
+		}
+		parentID := parent.GetId()
+		return func(startIndex int) ([]jellyfin.Item, int, error) {
+			return client.GetLibraryItems(parentID, jellyfin.Query{StartIndex: startIndex, Limit: pageSize, Sort: sort, Filter: filter})
+		}
+	}
+	switch m.currentTab {
 	case RecentlyAdded:
-		return func() tea.Msg {
-			items, err := client.GetRecentlyAdded()
-			if err != nil {
-				return fetchItemsResult{nil, err}
-			}
-			return fetchItemsResult{items, nil}
+		return func(startIndex int) ([]jellyfin.Item, int, error) {
+			return client.GetRecentlyAdded(jellyfin.Query{StartIndex: startIndex, Limit: pageSize, Sort: sort, Filter: filter})
+		}
+	case Favorites:
+		return func(startIndex int) ([]jellyfin.Item, int, error) {
+			return client.GetFavorites(jellyfin.Query{StartIndex: startIndex, Limit: pageSize, Sort: sort, Filter: filter})
 		}
 	case Search:
 		query := m.searchInput.Value()
-		return func() tea.Msg {
+		return func(startIndex int) ([]jellyfin.Item, int, error) {
 			if query == "" {
-				return fetchItemsResult{nil, nil}
+				return nil, 0, nil
 			}
-			items, err := client.Search(query)
-			if err != nil {
-				return fetchItemsResult{nil, err}
-			}
-			return fetchItemsResult{items, nil}
+			return client.Search(query, jellyfin.Query{StartIndex: startIndex, Limit: pageSize, Sort: sort, Filter: filter})
 		}
 	default:
-		panic("oops, selected tab is not in switch statement")
+		return nil
+	}
+}
+
+// queryable reports whether the current list is a paginated query that can be sorted and filtered on the server
+func (m model) queryable() bool {
+	if parent, ok := m.parent(); ok {
+		return jellyfin.IsLibrary(parent)
+	}
+	return m.currentTab == RecentlyAdded || m.currentTab == Favorites || m.currentTab == Search
+}
+
+type filterOptionsResult struct {
+	options jellyfin.FilterOptions
+	err     error
+}
+
+// fetchFilterOptions fetches the genres and ratings that can be picked in the filter panel
+func (m *model) fetchFilterOptions() tea.Cmd {
+	client := m.client
+	parentID := ""
+	if parent, ok := m.parent(); ok {
+		parentID = parent.GetId()
 	}
+	return func() tea.Msg {
+		options, err := client.GetFilterOptions(parentID)
+		return filterOptionsResult{options, err}
+	}
+}
+
+// openFilterPanel shows the filter panel filled in with the current tab's filter
+func (m *model) openFilterPanel() tea.Cmd {
+	m.filterPanelActive = true
+	m.filterOptions = jellyfin.FilterOptions{}
+	setFilterInputs(m.filterInputs, m.filters[m.currentTab])
+	m.currentFilterInput = 0
+	for i := range m.filterInputs {
+		m.filterInputs[i].Blur()
+	}
+	return tea.Batch(m.filterInputs[m.currentFilterInput].Focus(), m.fetchFilterOptions())
 }
 
+// applyFilterPanel sets the current tab's filter to the values in the filter panel
+func (m *model) applyFilterPanel() tea.Cmd {
+	filter, err := parseFilterInputs(m.filterInputs)
+	if err != nil {
+		m.err = err
+		return nil
+	}
+	m.err = nil
+	m.filterPanelActive = false
+	if filter.IsZero() {
+		delete(m.filters, m.currentTab)
+	} else {
+		m.filters[m.currentTab] = filter
+	}
+	return m.fetchItems()
+}
+
+// applySort sorts the current tab by the mode under the sort picker's cursor, or reverses the order if it's already sorted by it
+func (m *model) applySort() tea.Cmd {
+	mode := jellyfin.SortModes[m.sortCursor]
+	sort := mode.Sort
+	if current, ok := m.sorts[m.currentTab]; ok && current.By == mode.Sort.By {
+		sort = current.Reversed()
+	}
+	m.sorts[m.currentTab] = sort
+	if err := saveSort(m.currentTab, sort); err != nil {
+		m.err = err
+	}
+	return m.fetchItems()
+}
+
+// fetchMoreItems fetches the next page of a paginated list once the cursor gets close to the end of the loaded items
+func (m *model) fetchMoreItems() tea.Cmd {
+	if m.loading || len(m.allItems) >= m.totalItems || m.currentItem < len(m.items)-pageSize/5 {
+		return nil
+	}
+	fetchPage := m.pageFetcher()
+	if fetchPage == nil {
+		return nil
+	}
+	m.loading = true
+	startIndex, generation := len(m.allItems), m.generation
+	return func() tea.Msg {
+		items, total, err := fetchPage(startIndex)
+		if err != nil {
+			return fetchMoreItemsResult{nil, startIndex, 0, generation, err}
+		}
+		return fetchMoreItemsResult{items, startIndex, total, generation, nil}
+	}
+}
+
+// applyFilter ranks and filters allItems by the fuzzy filter query
 func (m *model) applyFilter() {
+	m.highlights = nil
 	if !m.filterActive || m.filterInput.Value() == "" {
 		m.items = m.allItems
 		if m.currentItem >= len(m.items) {
@@ -136,58 +408,166 @@ func (m *model) applyFilter() {
 		return
 	}
 
-	filterText := strings.ToLower(m.filterInput.Value())
-	var filtered []jellyfin.Item
-
+	query := parseFilterQuery(m.filterInput.Value())
+	type result struct {
+		item       jellyfin.Item
+		score      int
+		highlights []int
+	}
+	var results []result
 	for _, item := range m.allItems {
-		title := strings.ToLower(jellyfin.GetItemTitle(item))
-		desc := strings.ToLower(jellyfin.GetItemDescription(item))
-
-		if strings.Contains(title, filterText) || strings.Contains(desc, filterText) {
-			filtered = append(filtered, item)
+		if score, highlights, ok := query.match(item); ok {
+			results = append(results, result{item, score, highlights})
 		}
 	}
-
-	m.items = filtered
+	slices.SortStableFunc(results, func(a, b result) int {
+		return b.score - a.score
+	})
+
+	m.items = make([]jellyfin.Item, len(results))
+	m.highlights = make(map[string][]int, len(results))
+	for i, r := range results {
+		m.items[i] = r.item
+		m.highlights[r.item.GetId()] = r.highlights
+	}
 	if m.currentItem >= len(m.items) {
 		m.currentItem = 0
 	}
 }
 
 func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	next, cmd := m.update(msg)
+	m = next.(model)
+	if errors.Is(m.err, jellyfin.ErrUnauthorized) {
+		m.reauthenticate = true
+		return m, tea.Quit
+	}
+	// keep the poster in sync with whichever item ends up on screen
+	posterCmd := m.fetchPoster()
+	return m, tea.Batch(cmd, posterCmd)
+}
+
+func (m model) update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 
 	case error:
 		m.err = msg
 		return m, nil
 
+	case mediaSourcesResult:
+		m.loading = false
+		m.sourcesPending = false
+		if msg.err != nil {
+			m.err = msg.err
+			return m, nil
+		}
+		if len(msg.sources) < 2 {
+			cmd := m.playItem(msg.item, msg.fromStart, "")
+			m.updateKeys()
+			return m, cmd
+		}
+		m.sourcePickerActive = true
+		m.sourceItem = msg.item
+		m.sourceFromStart = msg.fromStart
+		m.sources = msg.sources
+		m.sourceCursor = 0
+		m.updateKeys()
+		return m, nil
+
+	case tokenChanged:
+		if err := config.SaveToken(msg.token); err != nil {
+			m.err = err
+		}
+		return m, nil
+
 	case playbackStopped:
 		if msg.err != nil {
 			m.err = msg.err
 		}
 		m.playing = nil
 		m.updateKeys()
-		return m, m.fetchItems()
+		return m, tea.Batch(m.fetchItems(), m.fetchDetail())
 
 	case toggleWatchedResult:
 		m.loading = false
 		if msg.err != nil {
 			m.err = msg.err
 		}
-		return m, m.fetchItems()
+		return m, tea.Batch(m.fetchItems(), m.fetchDetail())
+
+	case toggleFavoriteResult:
+		m.loading = false
+		if msg.err != nil {
+			m.err = msg.err
+		}
+		return m, tea.Batch(m.fetchItems(), m.fetchDetail())
+
+	case fetchDetailResult:
+		if msg.err != nil {
+			m.err = msg.err
+			return m, nil
+		}
+		if m.detail != nil && m.detail.GetId() == msg.item.GetId() {
+			m.detail = &msg.item
+			m.updateKeys()
+		}
+		return m, nil
 
 	case fetchItemsResult:
+		if msg.generation != m.generation {
+			// the user moved on to another list while it was being fetched
+			return m, nil
+		}
 		m.loading = false
 		if msg.err != nil {
 			m.err = msg.err
 		}
 		m.allItems = msg.items
+		m.totalItems = msg.total
 		m.filterInput.SetValue("")
 		m.filterActive = false
 		m.applyFilter()
 		m.updateKeys()
 		return m, nil
 
+	case fetchPosterResult:
+		if msg.key == m.posterKey {
+			// fetched again the next time it is needed if it failed
+			m.posterKey = ""
+		}
+		if msg.err != nil {
+			slog.Error("failed to fetch poster", "key", msg.key, "err", msg.err)
+			return m, nil
+		}
+		m.poster = &poster{key: msg.key, img: msg.img}
+		return m, nil
+
+	case filterOptionsResult:
+		if msg.err != nil {
+			m.err = msg.err
+		}
+		m.filterOptions = msg.options
+		return m, nil
+
+	case fetchMoreItemsResult:
+		if msg.generation != m.generation {
+			return m, nil
+		}
+		m.loading = false
+		if msg.err != nil {
+			m.err = msg.err
+			return m, nil
+		}
+		if msg.startIndex != len(m.allItems) {
+			// the list changed while the page was being fetched
+			return m, nil
+		}
+		m.allItems = append(m.allItems, msg.items...)
+		m.totalItems = msg.total
+		m.applyFilter()
+		m.updateKeys()
+		return m, nil
+
 	case tea.WindowSizeMsg:
 		m.width = msg.Width
 		m.height = msg.Height
@@ -236,9 +616,106 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			var cmd tea.Cmd
 			m.filterInput, cmd = m.filterInput.Update(msg)
 			m.applyFilter()
+			m.currentItem = 0 // best match
+			return m, cmd
+		}
+
+		if m.sourcePickerActive {
+			switch {
+			case key.Matches(msg, m.keyMap.CancelWhilePickingSource):
+				m.sourcePickerActive = false
+				m.updateKeys()
+				return m, nil
+			case key.Matches(msg, m.keyMap.AcceptWhilePickingSource):
+				m.sourcePickerActive = false
+				cmd := m.playItem(m.sourceItem, m.sourceFromStart, m.sources[m.sourceCursor].GetId())
+				m.updateKeys()
+				return m, cmd
+			case key.Matches(msg, m.keyMap.CursorUp):
+				m.sourceCursor = max(0, m.sourceCursor-1)
+				return m, nil
+			case key.Matches(msg, m.keyMap.CursorDown):
+				m.sourceCursor = min(len(m.sources)-1, m.sourceCursor+1)
+				return m, nil
+			}
+			return m, nil
+		}
+
+		if m.detail != nil {
+			switch {
+			case key.Matches(msg, m.keyMap.CloseDetails):
+				m.detail = nil
+				m.updateKeys()
+				return m, nil
+			case key.Matches(msg, m.keyMap.Select):
+				cmd := m.selectItem(*m.detail)
+				m.updateKeys()
+				return m, cmd
+			case key.Matches(msg, m.keyMap.PlayFromStart):
+				cmd := m.startPlayback(*m.detail, true)
+				m.updateKeys()
+				return m, cmd
+			case key.Matches(msg, m.keyMap.ToggleWatched):
+				return m, m.toggleWatchedStatus(*m.detail)
+			case key.Matches(msg, m.keyMap.ToggleFavorite):
+				return m, m.toggleFavoriteStatus(*m.detail)
+			case key.Matches(msg, m.keyMap.ShowFullHelp), key.Matches(msg, m.keyMap.CloseFullHelp):
+				m.help.ShowAll = !m.help.ShowAll
+				m.updateKeys()
+				return m, nil
+			case key.Matches(msg, m.keyMap.Quit):
+				return m, tea.Quit
+			}
+			return m, nil
+		}
+
+		if m.filterPanelActive {
+			switch {
+			case key.Matches(msg, m.keyMap.CancelWhileEditingFilters):
+				m.filterPanelActive = false
+				m.updateKeys()
+				return m, nil
+			case key.Matches(msg, m.keyMap.AcceptWhileEditingFilters):
+				cmd := m.applyFilterPanel()
+				m.updateKeys()
+				return m, cmd
+			case key.Matches(msg, m.keyMap.ClearFilters):
+				setFilterInputs(m.filterInputs, jellyfin.Filter{})
+				return m, nil
+			case key.Matches(msg, m.keyMap.NextFilterInput):
+				m.filterInputs[m.currentFilterInput].Blur()
+				m.currentFilterInput = (m.currentFilterInput + 1) % len(m.filterInputs)
+				return m, m.filterInputs[m.currentFilterInput].Focus()
+			case key.Matches(msg, m.keyMap.PrevFilterInput):
+				m.filterInputs[m.currentFilterInput].Blur()
+				m.currentFilterInput = (m.currentFilterInput - 1 + len(m.filterInputs)) % len(m.filterInputs)
+				return m, m.filterInputs[m.currentFilterInput].Focus()
+			}
+			var cmd tea.Cmd
+			m.filterInputs[m.currentFilterInput], cmd = m.filterInputs[m.currentFilterInput].Update(msg)
 			return m, cmd
 		}
 
+		if m.sortPickerActive {
+			switch {
+			case key.Matches(msg, m.keyMap.CancelWhileSorting):
+				m.sortPickerActive = false
+				m.updateKeys()
+				return m, nil
+			case key.Matches(msg, m.keyMap.AcceptWhileSorting):
+				m.sortPickerActive = false
+				m.updateKeys()
+				return m, m.applySort()
+			case key.Matches(msg, m.keyMap.CursorUp):
+				m.sortCursor = max(0, m.sortCursor-1)
+				return m, nil
+			case key.Matches(msg, m.keyMap.CursorDown):
+				m.sortCursor = min(len(jellyfin.SortModes)-1, m.sortCursor+1)
+				return m, nil
+			}
+			return m, nil
+		}
+
 		switch {
 		case key.Matches(msg, m.keyMap.CursorUp):
 			if m.currentItem > 0 {
@@ -251,7 +728,7 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.currentItem++
 			}
 			m.updateKeys()
-			return m, nil
+			return m, m.fetchMoreItems()
 		case key.Matches(msg, m.keyMap.PageUp):
 			jump := m.height / 5
 			m.currentItem = max(0, m.currentItem-jump)
@@ -261,11 +738,11 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			jump := m.height / 5
 			m.currentItem = min(len(m.items)-1, m.currentItem+jump)
 			m.updateKeys()
-			return m, nil
+			return m, m.fetchMoreItems()
 		case key.Matches(msg, m.keyMap.GoToEnd):
 			m.currentItem = len(m.items) - 1
 			m.updateKeys()
-			return m, nil
+			return m, m.fetchMoreItems()
 		case key.Matches(msg, m.keyMap.GoToStart):
 			m.currentItem = 0
 			m.updateKeys()
@@ -309,21 +786,37 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.updateKeys()
 			return m, nil
 
+		case key.Matches(msg, m.keyMap.EditFilters):
+			cmd := m.openFilterPanel()
+			m.updateKeys()
+			return m, cmd
+
+		case key.Matches(msg, m.keyMap.Sort):
+			m.sortPickerActive = true
+			m.sortCursor = 0
+			if current, ok := m.sorts[m.currentTab]; ok {
+				m.sortCursor = max(0, slices.IndexFunc(jellyfin.SortModes, func(mode jellyfin.SortMode) bool {
+					return mode.Sort.By == current.By
+				}))
+			}
+			m.updateKeys()
+			return m, nil
+
 		case key.Matches(msg, m.keyMap.Select):
+			cmd := m.selectItem(m.items[m.currentItem])
+			m.updateKeys()
+			return m, cmd
+
+		case key.Matches(msg, m.keyMap.ShowDetails):
 			item := m.items[m.currentItem]
-			if jellyfin.IsSeries(item) {
-				m.currentSeries = &item
-				m.updateKeys()
-				return m, m.fetchItems()
-			}
-			m.playing = &item
+			m.detail = &item
 			m.updateKeys()
-			return m, m.playItem()
+			return m, m.fetchDetail()
 
 		case key.Matches(msg, m.keyMap.Back):
-			m.currentSeries = nil
+			m.popFrame()
 			m.updateKeys()
-			return m, m.fetchItems()
+			return m, nil
 
 		case key.Matches(msg, m.keyMap.ShowFullHelp):
 			m.help.ShowAll = !m.help.ShowAll
@@ -336,11 +829,23 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m, nil
 
 		case key.Matches(msg, m.keyMap.ToggleWatched):
-			return m, m.toggleWatchedStatus()
+			return m, m.toggleWatchedStatus(m.items[m.currentItem])
+
+		case key.Matches(msg, m.keyMap.ToggleFavorite):
+			return m, m.toggleFavoriteStatus(m.items[m.currentItem])
 
 		case key.Matches(msg, m.keyMap.Refresh):
 			return m, m.fetchItems()
 
+		case key.Matches(msg, m.keyMap.SwitchProfile):
+			m.switchProfile = true
+			return m, tea.Quit
+
+		case key.Matches(msg, m.keyMap.CycleQuality):
+			names := qualities()
+			m.quality = names[(slices.Index(names, m.quality)+1)%len(names)]
+			return m, nil
+
 		case key.Matches(msg, m.keyMap.Quit):
 			return m, tea.Quit
 		default:
@@ -1,10 +1,13 @@
 package main
 
 import (
+	"fmt"
 	"math"
+	"strings"
 
 	"github.com/charmbracelet/lipgloss"
 	"github.com/charmbracelet/x/ansi"
+	"github.com/hacel/jfsh/internal/graphics"
 	"github.com/hacel/jfsh/internal/jellyfin"
 )
 
@@ -33,9 +36,23 @@ var (
 				Bold(true)
 	currentDescStyle = currentTitleStyle.Margin(0, 0, 1, 1).Foreground(pinkColor).UnsetBold()
 
+	highlightStyle = lipgloss.NewStyle().Foreground(brightPinkColor).Underline(true)
+
 	scrollbarStyle      = lipgloss.NewStyle().Foreground(dimTextColor)
 	scrollbarThumbStyle = lipgloss.NewStyle().Foreground(pinkColor)
 
+	sortStyle = tabStyle.UnsetBackground().Foreground(dimTextColor)
+
+	filterLabelStyle = lipgloss.NewStyle().Margin(0, 1, 0, 2).Width(12).Foreground(brightPinkColor)
+	filterHintStyle  = lipgloss.NewStyle().Margin(0, 0, 0, 2).Foreground(dimTextColor)
+
+	detailStyle      = lipgloss.NewStyle().Margin(0, 2).Foreground(textColor)
+	detailTitleStyle = lipgloss.NewStyle().Foreground(brightPinkColor).Bold(true)
+	detailLabelStyle = lipgloss.NewStyle().Foreground(pinkColor)
+	detailDimStyle   = lipgloss.NewStyle().Foreground(dimTextColor)
+
+	posterStyle = lipgloss.NewStyle().Margin(0, 2, 0, 1)
+
 	errStyle     = lipgloss.NewStyle().Foreground(errColor)
 	spinnerStyle = tabStyle.UnsetBackground().Foreground(brightPinkColor)
 )
@@ -66,9 +83,9 @@ func (m model) View() string {
 
 	{
 		var tabsView string
-		if m.currentSeries == nil {
+		if len(m.stack) == 0 {
 			var tabs []string
-			for i, name := range []string{ResumeTabName, NextUpTabName, RecentlyAddedTabName, SearchTabName} {
+			for i, name := range tabNames {
 				if tab(i) == m.currentTab {
 					tabs = append(tabs, currentTabStyle.Render(name))
 					continue
@@ -77,14 +94,34 @@ func (m model) View() string {
 			}
 			tabsView = lipgloss.JoinHorizontal(lipgloss.Top, tabs...)
 		} else {
-			tabsView = jellyfin.GetItemTitle(*m.currentSeries)
-			tabsView = currentTabStyle.Render(tabsView)
+			crumbs := []string{tabStyle.Render(tabNames[m.currentTab])}
+			for i, f := range m.stack {
+				name := ansi.Truncate(f.item.GetName(), 30, "…")
+				if i == len(m.stack)-1 {
+					crumbs = append(crumbs, currentTabStyle.Render(name))
+					continue
+				}
+				crumbs = append(crumbs, tabStyle.Render(name))
+			}
+			tabsView = lipgloss.JoinHorizontal(lipgloss.Top, crumbs...)
+		}
+		var sortView string
+		if sort, ok := m.sorts[m.currentTab]; ok && m.queryable() {
+			sortView = sortStyle.Render("Sort: " + sortName(sort))
+		}
+		var filterView string
+		if filter, ok := m.filters[m.currentTab]; ok && m.queryable() {
+			filterView = sortStyle.Render("Filter: " + ansi.Truncate(filterSummary(filter), 40, "…"))
+		}
+		var qualityView string
+		if m.quality != originalQuality {
+			qualityView = sortStyle.Render("Quality: " + m.quality)
 		}
 		var spinnerView string
 		if m.loading {
 			spinnerView = spinnerStyle.Render(m.spinner.View())
 		}
-		v := lipgloss.JoinHorizontal(lipgloss.Top, tabsView, spinnerView)
+		v := lipgloss.JoinHorizontal(lipgloss.Top, tabsView, sortView, filterView, qualityView, spinnerView)
 		sections = append(sections, v)
 		availHeight -= lipgloss.Height(v)
 	}
@@ -105,6 +142,10 @@ func (m model) View() string {
 		}
 	}
 
+	// kitty and sixel posters are drawn over the space left for them once the rest of the view is in place
+	var posterSequence string
+	var posterX, posterY int
+
 	var helpView string
 	{
 		helpView = m.help.View(m.keyMap)
@@ -113,7 +154,71 @@ func (m model) View() string {
 	}
 
 	{
-		if len(m.items) > 0 {
+		if m.sourcePickerActive {
+			itemViews := []string{titleStyle.Render("Play version of " + ansi.Truncate(jellyfin.GetItemTitle(m.sourceItem), max(m.width-20, 10), "…")), ""}
+			for i, source := range m.sources {
+				name := ansi.Truncate(jellyfin.GetMediaSourceDescription(source), max(m.width-4, 10), "…")
+				if i == m.sourceCursor {
+					itemViews = append(itemViews, currentTitleStyle.Render(name))
+				} else {
+					itemViews = append(itemViews, titleStyle.Render(name))
+				}
+			}
+			sections = append(sections, lipgloss.NewStyle().Height(availHeight).Render(lipgloss.JoinVertical(lipgloss.Left, itemViews...)))
+		} else if m.detail != nil {
+			width := m.width
+			var posterView string
+			if p, ok := m.currentPoster(); ok {
+				posterView, posterSequence = m.posterView(p, m.width/3, availHeight)
+				posterView = posterStyle.Render(posterView)
+				width -= lipgloss.Width(posterView)
+				posterX = width + posterStyle.GetMarginLeft()
+				posterY = lipgloss.Height(lipgloss.JoinVertical(lipgloss.Left, sections...))
+			}
+			sections = append(sections, lipgloss.JoinHorizontal(lipgloss.Top, m.detailView(width, availHeight), posterView))
+		} else if m.filterPanelActive {
+			var rows []string
+			for i, input := range m.filterInputs {
+				label := filterLabelStyle.Render(filterInputLabels[i])
+				hint := ""
+				switch {
+				case input.Err != nil:
+					hint = input.Err.Error()
+				case i == filterGenresInput:
+					hint = strings.Join(m.filterOptions.Genres, ", ")
+				case i == filterOfficialRatingsInput:
+					hint = strings.Join(m.filterOptions.OfficialRatings, ", ")
+				}
+				hint = filterHintStyle.Render(ansi.Truncate(hint, max(m.width-6, 0), "…"))
+				rows = append(rows, lipgloss.JoinHorizontal(lipgloss.Top, label, searchInputStyle.UnsetMargins().Render(input.View())), hint)
+			}
+			sections = append(sections, lipgloss.NewStyle().Height(availHeight).Render(lipgloss.JoinVertical(lipgloss.Left, rows...)))
+		} else if m.sortPickerActive {
+			current, hasCurrent := m.sorts[m.currentTab]
+			itemViews := []string{titleStyle.Render("Sort by"), ""}
+			for i, mode := range jellyfin.SortModes {
+				name := mode.Name
+				if hasCurrent && current.By == mode.Sort.By {
+					name = sortName(current)
+				}
+				if i == m.sortCursor {
+					itemViews = append(itemViews, currentTitleStyle.Render(name))
+				} else {
+					itemViews = append(itemViews, titleStyle.Render(name))
+				}
+			}
+			sections = append(sections, lipgloss.NewStyle().Height(availHeight).Render(lipgloss.JoinVertical(lipgloss.Left, itemViews...)))
+		} else if len(m.items) > 0 {
+			listWidth := m.width - 2
+			var posterView string
+			if p, ok := m.currentPoster(); ok {
+				posterView, posterSequence = m.posterView(p, min(m.width/3, 40), availHeight)
+				posterView = posterStyle.Render(posterView)
+				listWidth -= lipgloss.Width(posterView)
+				// the scrollbar sits between the list and the poster
+				posterX = listWidth + 1 + posterStyle.GetMarginLeft()
+				posterY = lipgloss.Height(lipgloss.JoinVertical(lipgloss.Left, sections...))
+			}
 			itemsPerPage := max(availHeight/3, 1)
 			firstItem := max(m.currentItem-itemsPerPage/2, 0)
 			if firstItem > len(m.items)-itemsPerPage {
@@ -127,28 +232,41 @@ func (m model) View() string {
 				desc := jellyfin.GetItemDescription(item)
 
 				// Prevent text from exceeding list width
-				textwidth := m.width - 6
+				textwidth := listWidth - 4
 				title = ansi.Truncate(title, textwidth, "…")
 				desc = ansi.Truncate(desc, textwidth, "…")
+				highlights := m.highlights[item.GetId()]
 				if i == m.currentItem {
+					if len(highlights) > 0 {
+						base := lipgloss.NewStyle().Foreground(brightPinkColor).Bold(true)
+						title = lipgloss.StyleRunes(title, highlights, base.Underline(true), base)
+					}
 					title = currentTitleStyle.Render(title)
 					desc = currentDescStyle.Render(desc)
 				} else {
+					style := titleStyle
 					if jellyfin.Watched(item) {
-						title = titleStyle.Foreground(dimTextColor).Render(title)
-					} else {
-						title = titleStyle.Render(title)
+						style = style.Foreground(dimTextColor)
+					}
+					if len(highlights) > 0 {
+						title = lipgloss.StyleRunes(title, highlights, highlightStyle, lipgloss.NewStyle().Foreground(style.GetForeground()))
 					}
+					title = style.Render(title)
 					desc = descStyle.Render(desc)
 				}
 				itemViews = append(itemViews, title, desc)
 			}
 			listContent := lipgloss.JoinVertical(lipgloss.Left, itemViews...)
-			listContent = lipgloss.NewStyle().Width(m.width - 2).Render(listContent)
+			listContent = lipgloss.NewStyle().Width(listWidth).Render(listContent)
 
 			scrollbarLines := make([]string, availHeight)
-			if len(m.items) > itemsPerPage {
-				thumbPosition := int(math.Round(float64(m.currentItem) / float64(len(m.items)-1) * float64(availHeight-1)))
+			// size the scrollbar to the full list when more pages are still to be fetched
+			total := len(m.items)
+			if !m.filterActive {
+				total = max(total, m.totalItems)
+			}
+			if total > itemsPerPage {
+				thumbPosition := int(math.Round(float64(m.currentItem) / float64(total-1) * float64(availHeight-1)))
 				for i := range availHeight {
 					if i == thumbPosition {
 						scrollbarLines[i] = scrollbarThumbStyle.Render("█")
@@ -158,12 +276,93 @@ func (m model) View() string {
 				}
 			}
 			scrollbarView := lipgloss.JoinVertical(lipgloss.Left, scrollbarLines...)
-			sections = append(sections, lipgloss.JoinHorizontal(lipgloss.Top, listContent, scrollbarView))
+			sections = append(sections, lipgloss.JoinHorizontal(lipgloss.Top, listContent, scrollbarView, posterView))
 		} else {
 			sections = append(sections, descStyle.Height(availHeight-1).Render("No items."))
 		}
 	}
 
 	sections = append(sections, helpView)
-	return lipgloss.JoinVertical(lipgloss.Left, sections...)
+	v := lipgloss.JoinVertical(lipgloss.Left, sections...)
+	if posterSequence != "" {
+		// save the cursor, move it to the poster's top left cell, draw and restore
+		return v + fmt.Sprintf("\x1b7\x1b[%d;%dH%s\x1b8", posterY+1, posterX+1, posterSequence)
+	}
+	return v + graphics.Clear(m.images)
+}
+
+// posterView returns the poster drawn in at most maxCols by maxRows cells. Images drawn with escape sequences can't be
+// part of the text, so blank space is returned in their place along with the sequence to draw over it.
+func (m model) posterView(p *poster, maxCols, maxRows int) (view, sequence string) {
+	rendered, cols, rows := p.render(m.images, maxCols, maxRows)
+	if m.images == graphics.HalfBlock {
+		return rendered, ""
+	}
+	blank := make([]string, rows)
+	for i := range blank {
+		blank[i] = strings.Repeat(" ", cols)
+	}
+	return strings.Join(blank, "\n"), rendered
+}
+
+// detailView renders the overview, people, genres and media streams of the item in the detail view
+func (m model) detailView(width, height int) string {
+	item := *m.detail
+	width = max(width-4, 10)
+	var lines []string
+
+	lines = append(lines, detailTitleStyle.Render(ansi.Truncate(jellyfin.GetItemTitle(item), width, "…")))
+	if jellyfin.IsEpisode(item) {
+		lines = append(lines, detailDimStyle.Render(ansi.Truncate(item.GetName(), width, "…")))
+	}
+	info := jellyfin.GetItemDescription(item)
+	if rating := item.GetOfficialRating(); rating != "" {
+		info += " | " + rating
+	}
+	lines = append(lines, detailDimStyle.Render(ansi.Truncate(info, width, "…")), "")
+
+	field := func(label string, values []string) {
+		if len(values) == 0 {
+			return
+		}
+		v := detailLabelStyle.Render(label+": ") + strings.Join(values, ", ")
+		lines = append(lines, lipgloss.NewStyle().Width(width).Render(v))
+	}
+	field("Genres", item.GetGenres())
+	field("Studios", jellyfin.GetItemStudios(item))
+	field("Crew", jellyfin.GetItemCrew(item))
+	field("Cast", jellyfin.GetItemCast(item))
+
+	if overview := item.GetOverview(); overview != "" {
+		lines = append(lines, "", lipgloss.NewStyle().Width(width).Render(overview))
+	}
+
+	if media := jellyfin.GetItemMediaInfo(item); len(media) > 0 {
+		lines = append(lines, "", detailLabelStyle.Render("Media"))
+		for _, stream := range media {
+			lines = append(lines, detailDimStyle.Render(ansi.Truncate(stream, width, "…")))
+		}
+	}
+
+	v := lipgloss.JoinVertical(lipgloss.Left, lines...)
+	// cut off whatever doesn't fit on screen
+	if rows := strings.Split(v, "\n"); len(rows) > height {
+		v = strings.Join(rows[:max(height, 0)], "\n")
+	}
+	return detailStyle.Height(height).Render(v)
+}
+
+// sortName returns the name of the sort mode with an arrow showing its direction
+func sortName(sort jellyfin.Sort) string {
+	name := string(sort.By)
+	for _, mode := range jellyfin.SortModes {
+		if mode.Sort.By == sort.By {
+			name = mode.Name
+			break
+		}
+	}
+	if sort.Descending() {
+		return name + " ↓"
+	}
+	return name + " ↑"
 }